@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/export"
+	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/AJMBrands/SoftwareThatMatters/ingest"
+	"github.com/spf13/cobra"
+)
+
+// seriesOptions are the flags of the series command
+var seriesOptions struct {
+	input, ecosystem string
+	from, to, step   string
+	metric, output   string
+	top              int
+}
+
+// seriesCmd represents the series command
+var seriesCmd = &cobra.Command{
+	Use:   "series",
+	Short: "Computes a metric of every package over time and writes it as a table",
+	Long: `Computes a metric of every package on the graph as it was at the start date, and at every step after
+that up to the end date, and writes a CSV table with one row per package and one column per date.
+For example, to follow the monthly PageRank of the packages in data/input/test_data.json:
+
+  stm-graph series -i test_data.json --from 01-01-2020 --to 01-01-2022 --step 1m --metric pagerank`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The flags were parsed, so errors from here on are not about how the command is used
+		cmd.SilenceUsage = true
+		return series()
+	},
+}
+
+// series creates the graph of the input, computes the series of the metric on it and writes it to the output.
+func series() error {
+	path := seriesOptions.input
+	if _, err := os.Stat(path); err != nil {
+		// Inputs can be given by their name in data/input, like start lists them
+		path = filepath.Join("data", "input", seriesOptions.input)
+	}
+	var ecosystem g.Ecosystem
+	if seriesOptions.ecosystem != "" {
+		var ok bool
+		if ecosystem, ok = g.LookupEcosystem(seriesOptions.ecosystem); !ok {
+			return fmt.Errorf("unknown ecosystem %q", seriesOptions.ecosystem)
+		}
+	} else if format, err := ingest.Detect(path); err != nil {
+		return err
+	} else if ecosystem = format.Ecosystem; ecosystem == nil {
+		return fmt.Errorf("the ecosystem of %s cannot be detected, choose one with --ecosystem", path)
+	}
+
+	if _, ok := g.Metrics[seriesOptions.metric]; !ok {
+		return fmt.Errorf("unknown metric %q, choose one of %s", seriesOptions.metric, strings.Join(metricNames(), ", "))
+	}
+	from, err := time.Parse("02-01-2006", seriesOptions.from)
+	if err != nil {
+		return errors.New("--from must be a date in the format: DD-MM-YYYY")
+	}
+	to, err := time.Parse("02-01-2006", seriesOptions.to)
+	if err != nil {
+		return errors.New("--to must be a date in the format: DD-MM-YYYY")
+	}
+	step, err := g.ParseStep(seriesOptions.step)
+	if err != nil {
+		return err
+	}
+
+	if seriesOptions.output == "-" {
+		// Only the series goes to standard output, so that it can be piped into other programs
+		progress, g.Progress = os.Stderr, os.Stderr
+	}
+	snapshot := loadOrCreateGraph(path, ecosystem)
+	fmt.Fprintf(progress, "Computing %s from %s to %s\n", seriesOptions.metric, seriesOptions.from, seriesOptions.to)
+	// The end of every day counts, so that the versions published on it are in its slice
+	endOfDay := 24*time.Hour - time.Nanosecond
+	result, err := g.CreateSeries(snapshot.Graph, snapshot.NodeInfo, seriesOptions.metric, from.Add(endOfDay), to.Add(endOfDay), step)
+	if err != nil {
+		return err
+	}
+	for i, t := range result.Times {
+		result.Times[i] = t.Add(-endOfDay)
+	}
+
+	if seriesOptions.output == "-" {
+		return export.SeriesCSV(os.Stdout, result, seriesOptions.top)
+	}
+	if err := os.MkdirAll(filepath.Dir(seriesOptions.output), 0755); err != nil {
+		return err
+	}
+	if err := export.SeriesFile(seriesOptions.output, result, seriesOptions.top); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the series to %s\n", seriesOptions.output)
+	return nil
+}
+
+// metricNames returns the names of the metrics a series can be computed for, sorted.
+func metricNames() []string {
+	names := make([]string, 0, len(g.Metrics))
+	for name := range g.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	rootCmd.AddCommand(seriesCmd)
+
+	seriesCmd.Flags().StringVarP(&seriesOptions.input, "input", "i", "", "Input file or directory, or its name in data/input")
+	seriesCmd.Flags().StringVarP(&seriesOptions.ecosystem, "ecosystem", "e", "", "Ecosystem of the input (default: the detected one)")
+	seriesCmd.Flags().StringVar(&seriesOptions.from, "from", "", "First date of the series (DD-MM-YYYY)")
+	seriesCmd.Flags().StringVar(&seriesOptions.to, "to", "", "Last date of the series (DD-MM-YYYY)")
+	seriesCmd.Flags().StringVar(&seriesOptions.step, "step", "1m", "Time between two dates: a number followed by d, w, m or y")
+	seriesCmd.Flags().StringVarP(&seriesOptions.metric, "metric", "m", "pagerank", "Metric to compute: "+strings.Join(metricNames(), ", "))
+	seriesCmd.Flags().IntVarP(&seriesOptions.top, "top", "n", 20, "Amount of packages to write, ordered by their highest value; 0 writes every package")
+	seriesCmd.Flags().StringVarP(&seriesOptions.output, "output", "o", "data/output/series.csv", "CSV file to write the series to, or - for standard output")
+	seriesCmd.Flags().IntVarP(&workers, "workers", "w", runtime.NumCPU(), "Amount of goroutines that create the edges of the graph")
+	seriesCmd.Flags().BoolVar(&useSnapshot, "snapshot", true, "Load the graph from a snapshot if the input has not changed, and save one after creating it")
+	for _, flag := range []string{"input", "from", "to"} {
+		_ = seriesCmd.MarkFlagRequired(flag)
+	}
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,11 +18,23 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 
+	"github.com/AJMBrands/SoftwareThatMatters/export"
 	g "github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/AJMBrands/SoftwareThatMatters/ingest"
 	"github.com/spf13/cobra"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
+// workers is the amount of goroutines that create the edges of the graph
+var workers int
+
+// useSnapshot makes start load the graph from a snapshot when its input has not changed
+var useSnapshot bool
+
+// progress is where the graph is reported to be loaded or created. The series command moves it to standard error
+// when it writes the series to standard output.
+var progress io.Writer = os.Stdout
+
 // startCmd represents the start command
 var startCmd = &cobra.Command{
 	Use:   "start",
@@ -42,9 +58,9 @@ func start() {
 	//	return nil
 	//}
 
-	fileNames := getJSONFilesFromDataFolder()
+	fileNames := getInputFilesFromDataFolder()
 	if len(*fileNames) == 0 {
-		fmt.Println("No JSON files found in data folder! Make sure there is at least one file in the data/input folder.")
+		fmt.Println("No readable input files found in data folder! Make sure there is at least one file in the data/input folder.")
 		return
 	}
 
@@ -59,12 +75,21 @@ func start() {
 	}
 	path := "data/input/" + file
 
-	isUsingMaven := false
-
-	usingMavenPrompt := &survey.Confirm{
-		Message: "Is the packages data coming from Maven?",
+	ecosystems := g.Ecosystems()
+	ecosystemNames := make([]string, len(ecosystems))
+	for i, ecosystem := range ecosystems {
+		ecosystemNames[i] = ecosystem.Name()
 	}
-	err = survey.AskOne(usingMavenPrompt, &isUsingMaven)
+	ecosystemPrompt := &survey.Select{
+		Message: "Which ecosystem is the packages data coming from?",
+		Options: ecosystemNames,
+	}
+	if format, err := ingest.Detect(path); err == nil && format.Ecosystem != nil {
+		ecosystemPrompt.Default = format.Ecosystem.Name()
+	}
+	ecosystemIndex := 0
+	err = survey.AskOne(ecosystemPrompt, &ecosystemIndex)
+	ecosystem := ecosystems[ecosystemIndex]
 
 	fmt.Println("Creating the graph. This may take a while!")
 	if err != nil {
@@ -77,7 +102,12 @@ func start() {
 	}()
 
 	//graph, packagesList, stringIDToNodeInfo, idToNodeInfo, nameToVersions := g.CreateGraph(path, isUsingMaven)
-	graph, hashMap, idToNodeInfo, _ := g.CreateGraph(path, isUsingMaven)
+	snapshot := loadOrCreateGraph(path, ecosystem)
+	graph, nodeIndex, idToNodeInfo, diagnostics := snapshot.Graph, snapshot.Nodes, snapshot.NodeInfo, snapshot.Diagnostics
+	fmt.Print(diagnostics)
+	// The analyses of the whole graph run on a compact, read-only copy of it. The queries of a time interval run on
+	// views of the graph, which leave it unchanged for the next query
+	compactGraph := g.NewCSRGraph(graph)
 
 	// TODO: remove this when we use the actual variables. It is here to get rid of the unused variables warning
 	//_, _, _, _, _ = g.CreateGraph(path, isUsingMaven)
@@ -96,6 +126,8 @@ func start() {
 				"Find the most used package",
 				"Find the xth most used unique packages (pagerank)",
 				"Find the xth most used packages (betweenness)",
+				"Resolve the dependencies of a package as of a date",
+				"Export the report of dependencies that could not be resolved",
 				"Quit",
 			},
 		}
@@ -114,28 +146,28 @@ func start() {
 			}
 		case 1:
 			fmt.Println("This should find all the possible dependencies of a package")
-			name := generateAndRunPackageNamePrompt("Please input the package name", idToNodeInfo)
-			nodes := g.GetTransitiveDependenciesNode(graph, idToNodeInfo, hashMap, name)
+			packageVersion := generateAndRunPackageVersionPrompt("Please input the package name", idToNodeInfo)
+			nodes := g.GetTransitiveDependenciesNode(graph, idToNodeInfo, nodeIndex, packageVersion)
 			for _, node := range *nodes {
 				fmt.Println(node)
 			}
 
 		case 2:
 			fmt.Println("This should find all the possible dependencies of a package between two timestamps")
-			nodes := findAllDependenciesOfAPackageBetweenTwoTimestamps(graph, hashMap, idToNodeInfo)
+			nodes := findAllDependenciesOfAPackageBetweenTwoTimestamps(graph, nodeIndex, idToNodeInfo)
 			for _, node := range *nodes {
 				fmt.Println(node)
 			}
 		case 3:
 			fmt.Println("This should find the latest dependencies of a package between two time stamps")
-			nodes := findLatestDependenciesOfAPackageBetweenTwotimestamps(graph, hashMap, idToNodeInfo)
+			nodes := findLatestDependenciesOfAPackageBetweenTwotimestamps(graph, nodeIndex, idToNodeInfo, ecosystem)
 
 			for _, node := range *nodes {
 				fmt.Println(node)
 			}
 		case 4:
 			fmt.Println("This should find the most used package")
-			pr := g.PageRank(graph)
+			pr := g.PageRank(compactGraph)
 			maxRank := 0.0
 			var mostUsedId int64
 			for id, rank := range pr {
@@ -148,7 +180,7 @@ func start() {
 		case 5:
 			fmt.Println("This should find the most used packages (unique)")
 			input := generateAndRunInt("Please input the number of packages desired")
-			pr := pageRankOnFilteredGraph(graph, hashMap, idToNodeInfo)
+			pr := pageRankOnFilteredGraph(graph, nodeIndex, idToNodeInfo, ecosystem)
 			keys := make([]int64, 0, len(pr))
 			for key := range pr {
 				keys = append(keys, key)
@@ -167,7 +199,7 @@ func start() {
 		case 6:
 			fmt.Println("This should find the n most used packages")
 			fmt.Println("Running betweenness algorithm")
-			betweenness := g.Betweenness(graph)
+			betweenness := g.Betweenness(compactGraph)
 			keys := make([]int64, 0, len(betweenness))
 			for k := range betweenness {
 				keys = append(keys, k)
@@ -183,6 +215,11 @@ func start() {
 				fmt.Printf("The %d-th highest-ranked node (%v) has a betweenness score of %f \n", i, idToNodeInfo[keys[i]], normalized)
 			}
 		case 7:
+			fmt.Println("This should find the dependencies a package resolved to on a date")
+			resolveDependenciesAtDate(graph, nodeIndex, idToNodeInfo, ecosystem)
+		case 8:
+			exportDiagnostics(diagnostics)
+		case 9:
 			fmt.Println("Stopping the program...")
 			stop = true
 		}
@@ -191,9 +228,10 @@ func start() {
 
 }
 
-// getJSONFilesFromDataFolder returns a slice of strings with the names of the JSON files in the data folder. It can
-// return an empty slice if there are no JSON files in the data folder so a check should be done after using this
-func getJSONFilesFromDataFolder() *[]string {
+// getInputFilesFromDataFolder returns a slice of strings with the names of the files and directories in the data
+// folder that one of the readers of the ingest package can read, either because of their name or their content. It can
+// return an empty slice if there are no such files in the data folder so a check should be done after using this
+func getInputFilesFromDataFolder() *[]string {
 
 	dir, err := os.Open("data/input")
 	if err != nil {
@@ -206,7 +244,7 @@ func getJSONFilesFromDataFolder() *[]string {
 	}
 	var fileNames []string
 	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".json") {
+		if _, err := ingest.Detect(filepath.Join("data/input", file.Name())); err == nil {
 			fileNames = append(fileNames, file.Name())
 		}
 
@@ -215,23 +253,13 @@ func getJSONFilesFromDataFolder() *[]string {
 }
 
 func findAllPackagesBetweenTwoTimestamps(idToNodeInfo map[int64]g.NodeInfo) *[]g.NodeInfo {
-	//// TODO: Discuss if we should create a copy or not. My idea is that we should create a copy of the graph and then
-	//// TODO: use the copy to find the packages. This way we can use the original graph for other operations.
-	//graphCopy := *graph
-
 	beginTime := generateAndRunDatePrompt("Please input the beginning date of the interval (DD-MM-YYYY)")
 	endTime := generateAndRunDatePrompt("Please input the end date of the interval (DD-MM-YYYY)")
 
 	var nodesInInterval []g.NodeInfo
 
 	for _, node := range idToNodeInfo {
-		//TODO: We need a way of properly parsing multiple times
-		nodeTime, err := time.Parse(time.RFC3339, node.Timestamp)
-		if err != nil {
-			fmt.Println("There was an error parsing the timestamps in the nodes!")
-			panic(err)
-		}
-		if g.InInterval(nodeTime, beginTime, endTime) {
+		if g.InInterval(node.Timestamp, beginTime, endTime) {
 			nodesInInterval = append(nodesInInterval, node)
 		}
 	}
@@ -240,21 +268,109 @@ func findAllPackagesBetweenTwoTimestamps(idToNodeInfo map[int64]g.NodeInfo) *[]g
 
 }
 
-func findAllDependenciesOfAPackageBetweenTwoTimestamps(graph *simple.DirectedGraph, hashMap map[uint64]int64, nodeMap map[int64]g.NodeInfo) *[]g.NodeInfo {
+func findAllDependenciesOfAPackageBetweenTwoTimestamps(graph *simple.DirectedGraph, nodeIndex *g.NodeIndex, nodeMap map[int64]g.NodeInfo) *[]g.NodeInfo {
 	beginTime := generateAndRunDatePrompt("Please input the beginning date of the interval (DD-MM-YYYY)")
 	endTime := generateAndRunDatePrompt("Please input the end date of the interval (DD-MM-YYYY)")
-	nodeStringId := generateAndRunPackageNamePrompt("Please select the name and the version of the package", nodeMap)
-	g.FilterGraph(graph, nodeMap, beginTime, endTime)
-	return g.GetTransitiveDependenciesNode(graph, nodeMap, hashMap, nodeStringId)
+	packageVersion := generateAndRunPackageVersionPrompt("Please select the name and the version of the package", nodeMap)
+	// Only the dependencies that were current at some moment of the interval are followed
+	window := g.ValidView(g.WindowView(graph, nodeMap, beginTime, endTime), beginTime, endTime)
+	return g.GetTransitiveDependenciesNode(window, nodeMap, nodeIndex, packageVersion)
 }
 
-func findLatestDependenciesOfAPackageBetweenTwotimestamps(graph *simple.DirectedGraph, hashMap map[uint64]int64, nodeMap map[int64]g.NodeInfo) *[]g.NodeInfo {
+func findLatestDependenciesOfAPackageBetweenTwotimestamps(graph *simple.DirectedGraph, nodeIndex *g.NodeIndex, nodeMap map[int64]g.NodeInfo, ecosystem g.Ecosystem) *[]g.NodeInfo {
 	beginTime := generateAndRunDatePrompt("Please input the beginning date of the interval (DD-MM-YYYY)")
 	endTime := generateAndRunDatePrompt("Please input the end date of the interval (DD-MM-YYYY)")
-	nodeStringId := generateAndRunPackageNamePrompt("Please select the name and the version of the package", nodeMap)
-	g.FilterNoTraversal(graph, nodeMap, beginTime, endTime)
-	g.LatestNoTraversal(graph, nodeMap)
-	return g.GetLatestTransitiveDependenciesNode(graph, nodeMap, hashMap, nodeStringId)
+	packageVersion := generateAndRunPackageVersionPrompt("Please select the name and the version of the package", nodeMap)
+	// Of the dependencies that were current at some moment of the interval, only the latest version of every package is
+	// kept
+	window := g.ValidView(g.WindowView(graph, nodeMap, beginTime, endTime), beginTime, endTime)
+	return g.GetLatestTransitiveDependenciesNode(window, nodeMap, nodeIndex, ecosystem, packageVersion)
+}
+
+func resolveDependenciesAtDate(graph *simple.DirectedGraph, nodeIndex *g.NodeIndex, nodeMap map[int64]g.NodeInfo, ecosystem g.Ecosystem) {
+	packageVersion := generateAndRunPackageVersionPrompt("Please select the name and the version of the package", nodeMap)
+	date := generateAndRunDatePrompt("Please input the date to resolve the dependencies on (DD-MM-YYYY)")
+	// The whole day counts, so that versions published later on the same day are included
+	resolved, err := g.ResolveAt(graph, nodeMap, nodeIndex, ecosystem, packageVersion, date.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(resolved)
+}
+
+// loadOrCreateGraph loads the graph of the input at path from its snapshot in data/output/snapshots. If there is no
+// snapshot yet, or the input has changed since it was made, the graph is created and a new snapshot is saved.
+func loadOrCreateGraph(path string, ecosystem g.Ecosystem) *g.Snapshot {
+	var checksum [32]byte
+	snapshotPath := filepath.Join("data", "output", "snapshots", filepath.Base(path)+"."+ecosystem.Name()+".snapshot")
+	if useSnapshot {
+		var err error
+		if checksum, err = ingest.Checksum(path); err != nil {
+			panic(err)
+		}
+		snapshot, err := g.LoadSnapshot(snapshotPath, ecosystem, checksum)
+		if err == nil {
+			fmt.Fprintf(progress, "Loaded the graph from %s\n", snapshotPath)
+			return snapshot
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(progress, "Creating the graph again: %v\n", err)
+		}
+	}
+
+	source, err := ingest.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	graph, nodeIndex, idToNodeInfo, versionIndex, diagnostics, err := g.CreateGraph(source, ecosystem, workers)
+	if err != nil {
+		panic(err)
+	}
+	for _, rowErr := range source.Skipped() {
+		fmt.Fprintf(progress, "Skipped malformed record in %s: %v\n", filepath.Base(path), rowErr)
+	}
+	source.Close()
+
+	snapshot := &g.Snapshot{Graph: graph, Nodes: nodeIndex, NodeInfo: idToNodeInfo, Versions: versionIndex, Diagnostics: diagnostics}
+	if useSnapshot {
+		if err := os.MkdirAll(filepath.Dir(snapshotPath), 0755); err != nil {
+			fmt.Fprintln(progress, err)
+		} else if err := g.SaveSnapshot(snapshotPath, snapshot, ecosystem, checksum); err != nil {
+			fmt.Fprintln(progress, err)
+		} else {
+			fmt.Fprintf(progress, "Saved a snapshot of the graph to %s\n", snapshotPath)
+		}
+	}
+	return snapshot
+}
+
+// exportDiagnostics asks for a file and writes the diagnostics report to it, as CSV or JSON depending on its extension.
+func exportDiagnostics(diagnostics *g.Diagnostics) {
+	pathPrompt := &survey.Input{
+		Message: "Please input the file to write the report to (.json or .csv)",
+		Default: "data/output/diagnostics.json",
+	}
+	path := ""
+	if err := survey.AskOne(pathPrompt, &path); err != nil {
+		panic(err)
+	}
+	var options export.Options
+	purlPrompt := &survey.Confirm{
+		Message: "Include the package URLs (purls) of the packages?",
+	}
+	if err := survey.AskOne(purlPrompt, &options.Purls); err != nil {
+		panic(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := export.DiagnosticsFile(path, diagnostics, options); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Wrote the report to %s\n", path)
 }
 
 func generateAndRunDatePrompt(message string) time.Time {
@@ -294,12 +410,10 @@ func generateAndRunDatePrompt(message string) time.Time {
 
 }
 
-func pageRankOnFilteredGraph(graph *simple.DirectedGraph, hashMap map[uint64]int64, nodeMap map[int64]g.NodeInfo) map[int64]float64 {
+func pageRankOnFilteredGraph(graph *simple.DirectedGraph, nodeIndex *g.NodeIndex, nodeMap map[int64]g.NodeInfo, ecosystem g.Ecosystem) map[int64]float64 {
 	beginTime := generateAndRunDatePrompt("Please input the beginning date of the interval (DD-MM-YYYY)")
 	endTime := generateAndRunDatePrompt("Please input the end date of the interval (DD-MM-YYYY)")
-	g.FilterNoTraversal(graph, nodeMap, beginTime, endTime)
-	g.LatestNoTraversal(graph, nodeMap)
-	return g.PageRank(graph)
+	return g.PageRank(g.LatestView(g.WindowView(graph, nodeMap, beginTime, endTime), nodeMap, ecosystem))
 }
 
 func generateAndRunInt(message string) int {
@@ -328,28 +442,52 @@ func generateAndRunInt(message string) int {
 	return ans
 }
 
-func generateAndRunPackageNamePrompt(message string, stringIDToNodeInfo map[int64]g.NodeInfo) string {
-	names := make([]string, 0, len(stringIDToNodeInfo))
-	for _, node := range stringIDToNodeInfo {
-		name := fmt.Sprintf("%s-%s", node.Name, node.Version)
-		names = append(names, name)
+// generateAndRunPackageVersionPrompt asks for a package version as a package URL (purl), such as the ones in SBOMs
+// and advisories, and suggests the package versions of the graph that contain what has been typed so far.
+func generateAndRunPackageVersionPrompt(message string, idToNodeInfo map[int64]g.NodeInfo) g.PackageVersion {
+	packageVersions := make(map[g.PackageVersion]bool, len(idToNodeInfo))
+	options := make([]string, 0, len(idToNodeInfo))
+	for _, node := range idToNodeInfo {
+		packageVersions[node.PackageVersion()] = true
+		options = append(options, node.PackageVersion().String())
+	}
+	sort.Strings(options)
+
+	validatePackageVersion := func(input interface{}) error {
+		str, _ := input.(string)
+		packageVersion, err := g.ParsePackageVersion(str)
+		if err != nil {
+			return err
+		}
+		if !packageVersions[packageVersion] {
+			return fmt.Errorf("%s is not in the graph", packageVersion)
+		}
+		return nil
 	}
-	packagePrompt := &survey.Select{
+	if len(options) > 0 {
+		message += " (e.g. " + options[0] + ")"
+	}
+	packagePrompt := &survey.Input{
 		Message: message,
-		Options: names,
+		Suggest: func(toComplete string) []string {
+			var suggestions []string
+			for _, option := range options {
+				if strings.Contains(option, toComplete) {
+					suggestions = append(suggestions, option)
+				}
+			}
+			return suggestions
+		},
 	}
-
-	//packagePrompt := &survey.Input{
-	//	Message: message,
-	//}
-	packageID := ""
-	err := survey.AskOne(packagePrompt, &packageID)
+	answer := ""
+	err := survey.AskOne(packagePrompt, &answer, survey.WithValidator(validatePackageVersion))
 
 	if err != nil {
 		panic(err)
 	}
 
-	return packageID
+	packageVersion, _ := g.ParsePackageVersion(answer)
+	return packageVersion
 }
 
 func init() {
@@ -364,4 +502,6 @@ func init() {
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
 	// startCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	startCmd.Flags().IntVarP(&workers, "workers", "w", runtime.NumCPU(), "Amount of goroutines that create the edges of the graph")
+	startCmd.Flags().BoolVar(&useSnapshot, "snapshot", true, "Load the graph from a snapshot if the input has not changed, and save one after creating it")
 }
@@ -1 +1,169 @@
+// Package export writes the results of the graph package to files that other tools can read.
 package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// Options change what the exports write.
+type Options struct {
+	// Purls adds the package URL of every package version, and of every dependency, so that the output can be
+	// cross-referenced with SBOMs and advisories.
+	Purls bool
+}
+
+// purlIssue is an issue with the package URLs of its package version and dependency.
+type purlIssue struct {
+	graph.Issue
+	Purl           string `json:"purl"`
+	DependencyPurl string `json:"dependencyPurl,omitempty"`
+}
+
+type purlIssueSummary struct {
+	graph.IssueSummary
+	Examples []purlIssue `json:"examples"`
+}
+
+type purlDiagnostics struct {
+	*graph.Diagnostics
+	Issues []purlIssueSummary `json:"issues"`
+}
+
+// issuePurls returns the package URLs of the package version and the dependency of the issue. The second one is empty
+// for issues that are not about a dependency.
+func issuePurls(diagnostics *graph.Diagnostics, issue graph.Issue) (string, string) {
+	dependencyPurl := ""
+	if dependency, ok := diagnostics.DependencyPackage(issue); ok {
+		dependencyPurl = dependency.String()
+	}
+	return diagnostics.PackageVersion(issue).String(), dependencyPurl
+}
+
+// DiagnosticsJSON writes the diagnostics report as an indented JSON object.
+func DiagnosticsJSON(w io.Writer, diagnostics *graph.Diagnostics, options Options) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if !options.Purls {
+		return encoder.Encode(diagnostics)
+	}
+	report := purlDiagnostics{Diagnostics: diagnostics}
+	for _, summary := range diagnostics.Issues {
+		withPurls := purlIssueSummary{IssueSummary: summary, Examples: make([]purlIssue, 0, len(summary.Examples))}
+		for _, issue := range summary.Examples {
+			purl, dependencyPurl := issuePurls(diagnostics, issue)
+			withPurls.Examples = append(withPurls.Examples, purlIssue{Issue: issue, Purl: purl, DependencyPurl: dependencyPurl})
+		}
+		report.Issues = append(report.Issues, withPurls)
+	}
+	return encoder.Encode(report)
+}
+
+// diagnosticsCSVHeader are the columns of DiagnosticsCSV. With package URLs, the purl and dependency_purl columns
+// are added.
+var diagnosticsCSVHeader = []string{"kind", "count", "package", "version", "dependency", "constraint", "detail"}
+
+// DiagnosticsCSV writes the diagnostics report as CSV with one row per example. The count column repeats the total
+// amount of issues of the kind of the example. Kinds without examples get a single row with only their count, so
+// every kind is present.
+func DiagnosticsCSV(w io.Writer, diagnostics *graph.Diagnostics, options Options) error {
+	writer := csv.NewWriter(w)
+	header := diagnosticsCSVHeader
+	if options.Purls {
+		header = append(header[:len(header):len(header)], "purl", "dependency_purl")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, summary := range diagnostics.Issues {
+		count := strconv.Itoa(summary.Count)
+		if len(summary.Examples) == 0 {
+			if err := writer.Write(append([]string{summary.Kind.String(), count}, make([]string, len(header)-2)...)); err != nil {
+				return err
+			}
+		}
+		for _, issue := range summary.Examples {
+			row := []string{summary.Kind.String(), count, issue.Package, issue.Version, issue.Dependency, issue.Constraint, issue.Detail}
+			if options.Purls {
+				purl, dependencyPurl := issuePurls(diagnostics, issue)
+				row = append(row, purl, dependencyPurl)
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// DiagnosticsFile writes the diagnostics report to the file at path, as CSV if its extension is .csv and as JSON
+// otherwise.
+func DiagnosticsFile(path string, diagnostics *graph.Diagnostics, options Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = DiagnosticsCSV(f, diagnostics, options)
+	} else {
+		err = DiagnosticsJSON(f, diagnostics, options)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing diagnostics to %s: %w", path, err)
+	}
+	return nil
+}
+
+// SeriesCSV writes the series as CSV with one row per package and one column per time, named by its date. Only the
+// top packages of the series are written, ordered by their highest value, or every package if top is not positive.
+func SeriesCSV(w io.Writer, series *graph.Series, top int) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(series.Times)+1)
+	header = append(header, "package")
+	for _, t := range series.Times {
+		header = append(header, t.Format("2006-01-02"))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, pkg := range series.Top(top) {
+		row := make([]string, 0, len(header))
+		row = append(row, pkg.String())
+		for _, value := range series.Values[pkg] {
+			row = append(row, strconv.FormatFloat(value, 'g', -1, 64))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SeriesFile writes the series as CSV to the file at path, like SeriesCSV does.
+func SeriesFile(path string, series *graph.Series, top int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = SeriesCSV(f, series, top)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing series to %s: %w", path, err)
+	}
+	return nil
+}
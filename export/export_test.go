@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestDiagnostics(t *testing.T) {
+	diagnostics := graph.NewDiagnostics(graph.DefaultMaxExamples)
+	diagnostics.Ecosystem = "npm"
+	diagnostics.Dependencies = 3
+	diagnostics.Edges = 1
+	diagnostics.Issues[graph.UnknownDependency].Count = 2
+	diagnostics.Issues[graph.UnknownDependency].Examples = []graph.Issue{
+		{Kind: graph.UnknownDependency, Package: "app", Version: "1.0.0", Dependency: "missing", Constraint: "^1.0.0"},
+		{Kind: graph.UnknownDependency, Package: "app", Version: "1.0.0", Dependency: "gone", Constraint: "*"},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := DiagnosticsJSON(&buffer, diagnostics, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Dependencies int
+			Issues       []struct {
+				Kind  string
+				Count int
+			}
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Dependencies != 3 || len(decoded.Issues) != len(graph.IssueKinds) {
+			t.Errorf("Unexpected report %+v", decoded)
+		}
+		if issue := decoded.Issues[graph.UnknownDependency]; issue.Kind != "unknown_dependency" || issue.Count != 2 {
+			t.Errorf("Unexpected issue summary %+v", issue)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := DiagnosticsCSV(&buffer, diagnostics, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The header, two examples and one row for each of the other kinds
+		if len(rows) != 1+2+len(graph.IssueKinds)-1 {
+			t.Fatalf("Unexpected amount of rows: %v", rows)
+		}
+		if rows[2][0] != "unknown_dependency" || rows[2][1] != "2" || rows[2][4] != "missing" {
+			t.Errorf("Unexpected row %v", rows[2])
+		}
+	})
+	t.Run("JSON with purls", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := DiagnosticsJSON(&buffer, diagnostics, Options{Purls: true}); err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Ecosystem string
+			Issues    []struct {
+				Count    int
+				Examples []struct {
+					Package        string
+					Purl           string
+					DependencyPurl string
+				}
+			}
+		}
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Ecosystem != "npm" || len(decoded.Issues) != len(graph.IssueKinds) {
+			t.Fatalf("Unexpected report %+v", decoded)
+		}
+		examples := decoded.Issues[graph.UnknownDependency].Examples
+		if len(examples) != 2 || examples[0].Package != "app" || examples[0].Purl != "pkg:npm/app@1.0.0" || examples[0].DependencyPurl != "pkg:npm/missing" {
+			t.Errorf("Unexpected examples %+v", examples)
+		}
+	})
+
+	t.Run("CSV with purls", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := DiagnosticsCSV(&buffer, diagnostics, Options{Purls: true}); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header := rows[0]; len(header) != 9 || header[7] != "purl" || header[8] != "dependency_purl" {
+			t.Errorf("Unexpected header %v", header)
+		}
+		if rows[2][7] != "pkg:npm/app@1.0.0" || rows[2][8] != "pkg:npm/missing" {
+			t.Errorf("Unexpected row %v", rows[2])
+		}
+	})
+}
+
+func TestSeriesCSV(t *testing.T) {
+	series := &graph.Series{
+		Metric: "dependents",
+		Times:  []time.Time{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+		Values: map[graph.PackageVersion][]float64{
+			{Ecosystem: "npm", Name: "small"}: {0, 1},
+			{Ecosystem: "npm", Name: "large"}: {2, 3.5},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := SeriesCSV(&buffer, series, 1); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"package", "2021-01-01", "2021-02-01"}, {"pkg:npm/large", "2", "3.5"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, rows)
+	}
+}
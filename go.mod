@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/AlecAivazis/survey/v2 v2.3.4
 	github.com/Masterminds/semver v1.5.0
+	github.com/klauspost/compress v1.15.15
 	github.com/mailru/easyjson v0.7.7
 	github.com/spf13/cobra v1.4.0
 	gonum.org/v1/gonum v0.11.0
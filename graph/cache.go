@@ -0,0 +1,230 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats counts how often a ResolutionCache could answer from memory.
+type CacheStats struct {
+	Lookups        uint64 `json:"lookups"`        // Dependencies resolved
+	Hits           uint64 `json:"hits"`           // Dependencies whose name and constraint were resolved before
+	Constraints    uint64 `json:"constraints"`    // Distinct name and constraint pairs, each parsed and matched once
+	ParsedVersions uint64 `json:"parsedVersions"` // Versions parsed, each once
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 if there were no lookups.
+func (s CacheStats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("%d lookups, %.1f%% hits, %d distinct constraints, %d versions parsed",
+		s.Lookups, s.HitRate()*100, s.Constraints, s.ParsedVersions)
+}
+
+type cachedVersion struct {
+	id      int64
+	version Version
+}
+
+// cachedPackage holds the parsed versions of a package. The versions that could not be parsed are left out, and
+// reported as issues by the lookup that parsed them.
+type cachedPackage struct {
+	known    bool // Whether the package is in the dataset at all
+	versions []cachedVersion
+}
+
+type resolutionKey struct {
+	name, constraint string
+}
+
+// resolution is the outcome of resolving a constraint against the versions of a package.
+type resolution struct {
+	ids []int64 // The nodes of the matching versions
+	// When a higher matching version than the one of ids[i] was first published, zero if no higher one has a timestamp
+	supersededAt []time.Time
+	known        bool
+	err          error // Why the constraint could not be parsed
+}
+
+// ResolutionCache resolves dependency constraints to the nodes of the versions that satisfy them. The versions of each
+// package are parsed only once, and the outcome of every distinct name and constraint pair is remembered, since many
+// packages depend on the same package with the same constraint. It is safe for concurrent use.
+type ResolutionCache struct {
+	ecosystem Ecosystem
+	nodes     *NodeIndex
+	nodeInfo  map[int64]NodeInfo
+	versions  *VersionIndex
+
+	mutex       sync.RWMutex
+	packages    map[string]*cachedPackage
+	resolutions map[resolutionKey]resolution
+
+	lookups, hits, constraints, parsedVersions uint64
+}
+
+// NewResolutionCache creates an empty cache for the nodes and versions of a graph. The NodeInfo of the nodes provides
+// the timestamps from which the validity of the edges is computed; it is only read.
+func NewResolutionCache(ecosystem Ecosystem, nodes *NodeIndex, nodeInfo map[int64]NodeInfo, versions *VersionIndex) *ResolutionCache {
+	return &ResolutionCache{
+		ecosystem:   ecosystem,
+		nodes:       nodes,
+		nodeInfo:    nodeInfo,
+		versions:    versions,
+		packages:    make(map[string]*cachedPackage),
+		resolutions: make(map[resolutionKey]resolution),
+	}
+}
+
+// Stats returns the statistics of the cache so far.
+func (c *ResolutionCache) Stats() CacheStats {
+	return CacheStats{
+		Lookups:        atomic.LoadUint64(&c.lookups),
+		Hits:           atomic.LoadUint64(&c.hits),
+		Constraints:    atomic.LoadUint64(&c.constraints),
+		ParsedVersions: atomic.LoadUint64(&c.parsedVersions),
+	}
+}
+
+// resolve returns the outcome of resolving the constraint against the versions of the package with the normalised
+// name. The issues are the versions of the package that could not be parsed, returned by the first lookup that
+// needed them only.
+func (c *ResolutionCache) resolve(name, constraint string) (resolution, []Issue) {
+	atomic.AddUint64(&c.lookups, 1)
+	key := resolutionKey{name, constraint}
+	c.mutex.RLock()
+	cached, ok := c.resolutions[key]
+	c.mutex.RUnlock()
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+		return cached, nil
+	}
+
+	pkg, issues := c.lookupPackage(name)
+	result := resolution{known: pkg.known}
+	parsed, err := c.ecosystem.ParseConstraint(constraint)
+	if err != nil {
+		result.err = err
+	} else {
+		var matching []cachedVersion
+		for _, v := range pkg.versions {
+			if parsed.Check(v.version) {
+				matching = append(matching, v)
+				result.ids = append(result.ids, v.id)
+			}
+		}
+		result.supersededAt = c.supersession(matching)
+		if picker, ok := c.ecosystem.(Picker); ok {
+			candidates := make([]Version, len(matching))
+			for i, v := range matching {
+				candidates[i] = v.version
+			}
+			c.pin(matching, picker.Pick(constraint, candidates), result.supersededAt)
+		}
+	}
+
+	c.mutex.Lock()
+	if _, ok := c.resolutions[key]; !ok { // Another goroutine may have resolved it in the meantime
+		c.resolutions[key] = result
+		atomic.AddUint64(&c.constraints, 1)
+	}
+	c.mutex.Unlock()
+	return result, issues
+}
+
+// lookupPackage returns the parsed versions of the package, parsing them on the first lookup.
+func (c *ResolutionCache) lookupPackage(name string) (*cachedPackage, []Issue) {
+	c.mutex.RLock()
+	pkg, ok := c.packages[name]
+	c.mutex.RUnlock()
+	if ok {
+		return pkg, nil
+	}
+
+	versions, known := c.versions.Lookup(name)
+	pkg = &cachedPackage{known: known}
+	var issues []Issue
+	for _, v := range versions {
+		parsed, err := c.ecosystem.ParseVersion(v)
+		if err != nil {
+			issues = append(issues, Issue{Kind: UnparsableVersion, Package: name, Version: v, Detail: err.Error()})
+			continue
+		}
+		id, ok := c.nodes.Lookup(PackageVersion{Ecosystem: c.ecosystem.Name(), Name: name, Version: v})
+		if !ok {
+			continue
+		}
+		pkg.versions = append(pkg.versions, cachedVersion{id: id, version: parsed})
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if existing, ok := c.packages[name]; ok { // Parsed by another goroutine in the meantime
+		return existing, nil
+	}
+	c.packages[name] = pkg
+	atomic.AddUint64(&c.parsedVersions, uint64(len(versions)))
+	return pkg, issues
+}
+
+// supersession returns for each of the versions that match a constraint when the first higher one of them was
+// published, which is when a package manager would stop resolving the constraint to it. Versions that are equal to
+// each other, such as 1.0 and 1.0.0 in Maven, do not supersede each other.
+func (c *ResolutionCache) supersession(matching []cachedVersion) []time.Time {
+	order := make([]int, len(matching))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { // Highest version first
+		return matching[order[i]].version.Compare(matching[order[j]].version) > 0
+	})
+
+	result := make([]time.Time, len(matching))
+	var earliest time.Time // The earliest timestamp of the versions higher than the current ones
+	for i := 0; i < len(order); {
+		groupEarliest := earliest
+		j := i
+		for ; j < len(order) && matching[order[j]].version.Compare(matching[order[i]].version) == 0; j++ {
+			result[order[j]] = earliest
+			if published := c.nodeInfo[matching[order[j]].id].Timestamp; !published.IsZero() &&
+				(groupEarliest.IsZero() || published.Before(groupEarliest)) {
+				groupEarliest = published
+			}
+		}
+		earliest = groupEarliest
+		i = j
+	}
+	return result
+}
+
+// pin changes the supersession of the versions that match a constraint when the ecosystem picked one of them: the
+// versions equal to the picked one are never superseded, and the others are superseded once the first of those is
+// published, even if they are higher.
+func (c *ResolutionCache) pin(matching []cachedVersion, picked int, supersededAt []time.Time) {
+	if picked < 0 || picked >= len(matching) {
+		return
+	}
+	pinned := matching[picked].version
+	var pinnedAt time.Time // When the first of the versions equal to the picked one was published
+	for _, v := range matching {
+		if published := c.nodeInfo[v.id].Timestamp; v.version.Compare(pinned) == 0 && !published.IsZero() &&
+			(pinnedAt.IsZero() || published.Before(pinnedAt)) {
+			pinnedAt = published
+		}
+	}
+	for i, v := range matching {
+		switch {
+		case v.version.Compare(pinned) == 0:
+			supersededAt[i] = time.Time{}
+		case !pinnedAt.IsZero() && (supersededAt[i].IsZero() || pinnedAt.Before(supersededAt[i])):
+			supersededAt[i] = pinnedAt
+		}
+	}
+}
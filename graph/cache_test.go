@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// countingEcosystem counts how often versions and constraints are parsed.
+type countingEcosystem struct {
+	Ecosystem
+	versions, constraints int
+}
+
+func (e *countingEcosystem) ParseVersion(version string) (Version, error) {
+	e.versions++
+	return e.Ecosystem.ParseVersion(version)
+}
+
+func (e *countingEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	e.constraints++
+	return e.Ecosystem.ParseConstraint(constraint)
+}
+
+func TestResolutionCache(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{Name: "lib", Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2020-01-01T00:00:00Z"},
+			"1.1.0": {Timestamp: "2020-06-01T00:00:00Z"},
+			"2.0.0": {Timestamp: "2021-01-01T00:00:00Z"},
+			"nope":  {Timestamp: "2021-02-01T00:00:00Z"},
+		}},
+	}
+	for i := 0; i < 10; i++ {
+		packagesInfo = append(packagesInfo, PackageInfo{Name: fmt.Sprintf("app-%d", i), Versions: map[string]VersionInfo{
+			"1.0.0": {Timestamp: "2021-01-01T00:00:00Z", Dependencies: map[string]string{"lib": "^1.0.0"}},
+			"2.0.0": {Timestamp: "2021-01-01T00:00:00Z", Dependencies: map[string]string{"lib": ">=2.0.0"}},
+		}})
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	ecosystem := &countingEcosystem{Ecosystem: NPM}
+	diagnostics := CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, ecosystem)
+
+	if ecosystem.versions != 4 || ecosystem.constraints != 2 {
+		t.Errorf("Expected 4 versions and 2 constraints to be parsed, got %d and %d", ecosystem.versions, ecosystem.constraints)
+	}
+	expected := CacheStats{Lookups: 20, Hits: 18, Constraints: 2, ParsedVersions: 4}
+	if diagnostics.Cache != expected {
+		t.Errorf("Expected cache stats %+v, got %+v", expected, diagnostics.Cache)
+	}
+	if rate := diagnostics.Cache.HitRate(); rate != 0.9 {
+		t.Errorf("Expected a hit rate of 0.9, got %f", rate)
+	}
+	if diagnostics.Edges != 30 {
+		t.Errorf("Expected 30 edges, got %d", diagnostics.Edges)
+	}
+	if count := diagnostics.Count(UnparsableVersion); count != 1 {
+		t.Errorf("Expected the unparsable version to be reported once, got %d", count)
+	}
+}
+
+func TestResolutionCacheMatchesUncachedResolution(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, Maven)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	cache := NewResolutionCache(Maven, nodeIndex, nodeMap, versionIndex)
+
+	for _, packageInfo := range packagesInfo {
+		for _, versionInfo := range packageInfo.Versions {
+			for dependencyName, constraint := range versionInfo.Dependencies {
+				expected := make(map[int64]bool)
+				if parsed, err := Maven.ParseConstraint(constraint); err == nil {
+					versions, _ := versionIndex.Lookup(dependencyName)
+					for _, version := range versions {
+						if v, err := Maven.ParseVersion(version); err == nil && parsed.Check(v) {
+							expected[nodeId(t, nodeIndex, PackageVersion{Ecosystem: "maven", Name: dependencyName, Version: version}.String())] = true
+						}
+					}
+				}
+				resolved, _ := cache.resolve(dependencyName, constraint)
+				if len(resolved.ids) != len(expected) {
+					t.Errorf("%s %s: expected %d versions, got %d", dependencyName, constraint, len(expected), len(resolved.ids))
+				}
+				for _, id := range resolved.ids {
+					if !expected[id] {
+						t.Errorf("%s %s: unexpected node %d", dependencyName, constraint, id)
+					}
+				}
+			}
+		}
+	}
+	if stats := cache.Stats(); stats.Lookups != stats.Hits+stats.Constraints {
+		t.Errorf("Unexpected cache stats %+v", stats)
+	}
+}
+
+// TestResolutionCacheConcurrentLookups resolves the same constraints from many goroutines at once, the way the
+// workers of CreateEdgesConcurrent share the cache. Run it with -race to check the cache for data races.
+func TestResolutionCacheConcurrentLookups(t *testing.T) {
+	packagesInfo := randomPackages(200)
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	cache := NewResolutionCache(NPM, nodeIndex, nodeMap, versionIndex)
+	constraints := []string{"^1.0.0", "~1.1.0", ">=1.2.0", "*", "not a range"}
+
+	const workers = 8
+	results := make([]map[resolutionKey]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			results[w] = make(map[resolutionKey]int)
+			for i := range packagesInfo {
+				name := packagesInfo[(i+w*25)%len(packagesInfo)].Name // Every worker starts at another package
+				for _, constraint := range constraints {
+					resolved, _ := cache.resolve(name, constraint)
+					results[w][resolutionKey{name, constraint}] = len(resolved.ids)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 1; w < workers; w++ {
+		if !reflect.DeepEqual(results[w], results[0]) {
+			t.Errorf("Worker %d resolved the constraints differently from worker 0", w)
+		}
+	}
+	stats := cache.Stats()
+	expectedLookups := uint64(workers * len(packagesInfo) * len(constraints))
+	if stats.Lookups != expectedLookups || stats.Constraints != uint64(len(results[0])) || stats.Hits+stats.Constraints > stats.Lookups {
+		t.Errorf("Unexpected cache stats %+v after %d lookups of %d constraints", stats, expectedLookups, len(results[0]))
+	}
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cargoPartial is a version of a Cargo requirement, in which the minor and patch numbers can be left out.
+type cargoPartial struct {
+	numbers    []uint64 // One to three numbers
+	prerelease string
+}
+
+func parseCargoPartial(s string) (cargoPartial, error) {
+	var partial cargoPartial
+	s = strings.TrimSpace(s)
+	if plus := strings.IndexByte(s, '+'); plus >= 0 {
+		s = s[:plus]
+	}
+	if dash := strings.IndexByte(s, '-'); dash >= 0 {
+		s, partial.prerelease = s[:dash], s[dash+1:]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return partial, fmt.Errorf("invalid version %q", s)
+	}
+	for _, part := range parts {
+		if part == "*" || part == "x" || part == "X" {
+			break
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return partial, fmt.Errorf("invalid version %q", s)
+		}
+		partial.numbers = append(partial.numbers, n)
+	}
+	return partial, nil
+}
+
+// lower returns the smallest version the partial version stands for.
+func (p cargoPartial) lower() string {
+	numbers := [3]uint64{}
+	copy(numbers[:], p.numbers)
+	version := fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2])
+	if p.prerelease != "" {
+		version += "-" + p.prerelease
+	}
+	return version
+}
+
+// bump returns the version following every version that starts with the first n numbers of the partial version.
+func (p cargoPartial) bump(n int) string {
+	numbers := [3]uint64{}
+	copy(numbers[:], p.numbers[:n])
+	numbers[n-1]++
+	return fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2])
+}
+
+// caretUpper returns the upper bound of ^p: the first version that changes the leftmost non-zero number.
+func (p cargoPartial) caretUpper() string {
+	for i, n := range p.numbers {
+		if n != 0 || i == len(p.numbers)-1 {
+			return p.bump(i + 1)
+		}
+	}
+	return ""
+}
+
+// translateCargoRequirement translates a Cargo version requirement to a semver constraint. Cargo reads a bare version
+// as a caret requirement, and its caret is stricter for 0.x versions than the one of npm: ^0.2.3 means >=0.2.3 <0.3.0.
+// Every comparator is therefore written as an explicit range.
+func translateCargoRequirement(requirement string) (string, error) {
+	requirement = strings.TrimSpace(requirement)
+	if requirement == "" || requirement == "*" {
+		return "*", nil
+	}
+	var ranges []string
+	for _, comparator := range strings.Split(requirement, ",") {
+		comparator = strings.TrimSpace(comparator)
+		operator := strings.TrimRight(comparator[:len(comparator)-len(strings.TrimLeft(comparator, "=<>~^"))], " ")
+		partial, err := parseCargoPartial(strings.TrimLeft(comparator, "=<>~^ "))
+		if err != nil {
+			return "", err
+		}
+		n := len(partial.numbers)
+		if n == 0 {
+			if operator != "" {
+				return "", fmt.Errorf("invalid requirement %q", comparator)
+			}
+			continue // A bare wildcard does not restrict the versions
+		}
+		wildcard := strings.ContainsAny(comparator, "*xX")
+		switch {
+		case operator == "" && !wildcard, operator == "^":
+			ranges = append(ranges, ">="+partial.lower(), "<"+partial.caretUpper())
+		case operator == "~":
+			// A tilde requirement allows patch updates if it has a minor version, and minor updates otherwise
+			precision := n
+			if precision > 2 {
+				precision = 2
+			}
+			ranges = append(ranges, ">="+partial.lower(), "<"+partial.bump(precision))
+		case operator == "" || operator == "=":
+			if n == 3 {
+				ranges = append(ranges, "="+partial.lower())
+			} else {
+				ranges = append(ranges, ">="+partial.lower(), "<"+partial.bump(n))
+			}
+		case operator == ">":
+			if n == 3 {
+				ranges = append(ranges, ">"+partial.lower())
+			} else {
+				ranges = append(ranges, ">="+partial.bump(n))
+			}
+		case operator == ">=":
+			ranges = append(ranges, ">="+partial.lower())
+		case operator == "<":
+			ranges = append(ranges, "<"+partial.lower())
+		case operator == "<=":
+			if n == 3 {
+				ranges = append(ranges, "<="+partial.lower())
+			} else {
+				ranges = append(ranges, "<"+partial.bump(n))
+			}
+		default:
+			return "", fmt.Errorf("invalid operator %q in requirement %q", operator, comparator)
+		}
+	}
+	if len(ranges) == 0 {
+		return "*", nil
+	}
+	return strings.Join(ranges, ", "), nil
+}
+
+// cargoEcosystem uses semantic versioning with the requirement syntax of Cargo, in which a bare version is a caret
+// requirement.
+type cargoEcosystem struct{}
+
+func (cargoEcosystem) Name() string {
+	return "cargo"
+}
+
+func (cargoEcosystem) NormalizeName(name string) string {
+	return name
+}
+
+func (cargoEcosystem) ParseVersion(version string) (Version, error) {
+	return parseSemverVersion(version)
+}
+
+func (cargoEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	translated, err := translateCargoRequirement(constraint)
+	if err != nil {
+		return nil, err
+	}
+	return parseSemverConstraint(translated)
+}
+
+func (cargoEcosystem) ParseTimestamp(timestamp string) (time.Time, error) {
+	return parseTimestamp(timestamp, time.RFC3339)
+}
+
+func (cargoEcosystem) PurlName(name string) (string, string) {
+	return "", name
+}
+
+func (e cargoEcosystem) ParsePurlName(namespace, name string) (string, error) {
+	return withoutPurlNamespace(e, namespace, name)
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestTranslateCargoRequirement(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3":         ">=1.2.3, <2.0.0",
+		"^0.2.3":        ">=0.2.3, <0.3.0",
+		"0.0.3":         ">=0.0.3, <0.0.4",
+		"^0.0":          ">=0.0.0, <0.1.0",
+		"^0":            ">=0.0.0, <1.0.0",
+		"~1.2":          ">=1.2.0, <1.3.0",
+		"~1":            ">=1.0.0, <2.0.0",
+		"1.*":           ">=1.0.0, <2.0.0",
+		"=1.2":          ">=1.2.0, <1.3.0",
+		"=1.2.3":        "=1.2.3",
+		">1.2":          ">=1.3.0",
+		"<=1":           "<2.0.0",
+		">= 1.2, < 1.5": ">=1.2.0, <1.5.0",
+		"*":             "*",
+		"^1.0.0-alpha":  ">=1.0.0-alpha, <2.0.0",
+	}
+	for requirement, expected := range cases {
+		translated, err := translateCargoRequirement(requirement)
+		if err != nil || translated != expected {
+			t.Errorf("Expected %q to translate to %q, got %q (%v)", requirement, expected, translated, err)
+		}
+	}
+	for _, invalid := range []string{"1.2.3.4", ">=banana", "1.2,"} {
+		if _, err := translateCargoRequirement(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestCreateEdgesCargo(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp:    "2021-01-01T00:00:00Z",
+					Dependencies: map[string]string{"rand": "0.7.2", "cc": "1"},
+					Kinds:        map[string]string{"cc": "build"},
+				},
+			},
+		},
+		{
+			Name: "rand",
+			Versions: map[string]VersionInfo{
+				"0.7.2": {Timestamp: "2019-01-01T00:00:00Z"},
+				"0.7.3": {Timestamp: "2020-01-01T00:00:00Z"},
+				"0.8.0": {Timestamp: "2020-06-01T00:00:00Z"},
+			},
+		},
+		{
+			Name:     "cc",
+			Versions: map[string]VersionInfo{"1.0.50": {Timestamp: "2020-01-01T00:00:00Z"}},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, Cargo)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, Cargo)
+
+	from := nodeId(t, nodeIndex, "pkg:cargo/app@1.0.0")
+	if edges := graph.From(from).Len(); edges != 3 {
+		t.Errorf("Expected 3 edges, got %d", edges)
+	}
+	if graph.Edge(from, nodeId(t, nodeIndex, "pkg:cargo/rand@0.8.0")) != nil {
+		t.Error("Expected 0.7.2 not to match rand 0.8.0")
+	}
+	edge, ok := graph.Edge(from, nodeId(t, nodeIndex, "pkg:cargo/cc@1.0.50")).(GraphEdge)
+	if !ok || edge.Kind != "build" {
+		t.Errorf("Expected a build edge to cc, got %v", edge)
+	}
+}
@@ -0,0 +1,269 @@
+package graph
+
+import (
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// CSRGraph is an immutable directed graph stored in compressed sparse row form: the nodes are kept as a sorted slice
+// of IDs, and the edges as one slice of targets per direction, with an offset per node into it. It needs a fraction of
+// the memory of a simple.DirectedGraph, which keeps maps for every node, and it implements graph.Directed, so PageRank,
+// Betweenness and the traversals of gonum can run on it. Use NewCSRGraph once the graph will not change anymore.
+type CSRGraph struct {
+	ids []int64 // The IDs of the nodes in ascending order; a node is referred to by its position in it
+
+	// The targets of the edges from the node at position i are outTargets[outOffsets[i]:outOffsets[i+1]], in
+	// ascending order, and their kinds are kinds[outKinds[...]] over the same range. The validity of the edges is kept
+	// over the same range as well
+	outOffsets []uint32
+	outTargets []uint32
+	outKinds   []uint8
+	validFrom  []csrTime
+	validUntil []csrTime
+	// The sources of the edges to the node at position i are inSources[inOffsets[i]:inOffsets[i+1]]
+	inOffsets []uint32
+	inSources []uint32
+
+	kinds []string // The distinct kinds of the edges, with the empty kind of regular dependencies first
+}
+
+// CSREdge is an edge of a CSRGraph.
+type CSREdge struct {
+	FId, TId int64
+	Kind     string   // Kind of the dependency, empty for regular dependencies
+	Valid    Validity // When the dependency resolved to this version
+}
+
+func (e CSREdge) From() graph.Node {
+	return simple.Node(e.FId)
+}
+
+func (e CSREdge) To() graph.Node {
+	return simple.Node(e.TId)
+}
+
+func (e CSREdge) ReversedEdge() graph.Edge {
+	return CSREdge{FId: e.TId, TId: e.FId, Kind: e.Kind, Valid: e.Valid}
+}
+
+// NewCSRGraph copies a directed graph into a CSRGraph. Node IDs are kept, and so are the kinds and validities of the
+// edges if they are GraphEdges. At most 255 distinct kinds of edges can be kept; any further kinds are stored as
+// regular edges.
+func NewCSRGraph(g graph.Directed) *CSRGraph {
+	csr := &CSRGraph{kinds: []string{""}}
+	nodes := g.Nodes()
+	if nodes.Len() > 0 {
+		csr.ids = make([]int64, 0, nodes.Len())
+	}
+	for nodes.Next() {
+		csr.ids = append(csr.ids, nodes.Node().ID())
+	}
+	sort.Slice(csr.ids, func(i, j int) bool { return csr.ids[i] < csr.ids[j] })
+
+	kindCodes := map[string]uint8{"": 0}
+	inDegrees := make([]uint32, len(csr.ids))
+	csr.outOffsets = make([]uint32, len(csr.ids)+1)
+	for i, id := range csr.ids {
+		start := len(csr.outTargets)
+		targets := g.From(id)
+		for targets.Next() {
+			to := targets.Node().ID()
+			target, _ := csr.position(to)
+			kind, valid := "", Validity{}
+			if edge, ok := g.Edge(id, to).(GraphEdge); ok {
+				kind, valid = edge.Kind, edge.Valid
+			}
+			code, ok := kindCodes[kind]
+			if !ok && len(csr.kinds) <= 255 {
+				code = uint8(len(csr.kinds))
+				kindCodes[kind] = code
+				csr.kinds = append(csr.kinds, kind)
+			}
+			csr.outTargets = append(csr.outTargets, target)
+			csr.outKinds = append(csr.outKinds, code)
+			csr.validFrom = append(csr.validFrom, newCSRTime(valid.From))
+			csr.validUntil = append(csr.validUntil, newCSRTime(valid.Until))
+			inDegrees[target]++
+		}
+		sort.Sort(csrEdgeRange{targets: csr.outTargets[start:], kinds: csr.outKinds[start:], from: csr.validFrom[start:], until: csr.validUntil[start:]})
+		csr.outOffsets[i+1] = uint32(len(csr.outTargets))
+	}
+
+	// The sources of every node are filled in by walking the edges in the order of their sources, so they end up
+	// sorted as well
+	csr.inOffsets = make([]uint32, len(csr.ids)+1)
+	for i, degree := range inDegrees {
+		csr.inOffsets[i+1] = csr.inOffsets[i] + degree
+	}
+	csr.inSources = make([]uint32, len(csr.outTargets))
+	next := append([]uint32(nil), csr.inOffsets[:len(csr.ids)]...)
+	for source := range csr.ids {
+		for _, target := range csr.outTargets[csr.outOffsets[source]:csr.outOffsets[source+1]] {
+			csr.inSources[next[target]] = uint32(source)
+			next[target]++
+		}
+	}
+	return csr
+}
+
+// csrEdgeRange sorts the targets of a node together with the kinds and validities of their edges.
+type csrEdgeRange struct {
+	targets     []uint32
+	kinds       []uint8
+	from, until []csrTime
+}
+
+func (r csrEdgeRange) Len() int           { return len(r.targets) }
+func (r csrEdgeRange) Less(i, j int) bool { return r.targets[i] < r.targets[j] }
+func (r csrEdgeRange) Swap(i, j int) {
+	r.targets[i], r.targets[j] = r.targets[j], r.targets[i]
+	r.kinds[i], r.kinds[j] = r.kinds[j], r.kinds[i]
+	r.from[i], r.from[j] = r.from[j], r.from[i]
+	r.until[i], r.until[j] = r.until[j], r.until[i]
+}
+
+// csrTime is a time as Unix seconds and nanoseconds, which takes less memory than a time.Time. Unlike Unix
+// nanoseconds alone, it holds every time, including the zero time of an unbounded validity.
+type csrTime struct {
+	seconds int64
+	nanos   int32
+}
+
+func newCSRTime(t time.Time) csrTime {
+	return csrTime{seconds: t.Unix(), nanos: int32(t.Nanosecond())}
+}
+
+// time returns the time, in UTC like every timestamp of the graph.
+func (t csrTime) time() time.Time {
+	return time.Unix(t.seconds, int64(t.nanos)).UTC()
+}
+
+// position returns the position of the node with the ID, and whether the graph has such a node.
+func (csr *CSRGraph) position(id int64) (uint32, bool) {
+	i := sort.Search(len(csr.ids), func(i int) bool { return csr.ids[i] >= id })
+	return uint32(i), i < len(csr.ids) && csr.ids[i] == id
+}
+
+// edgePosition returns the position of the edge from the node at position from to the one at position to in
+// outTargets, and whether there is such an edge.
+func (csr *CSRGraph) edgePosition(from, to uint32) (uint32, bool) {
+	start, end := csr.outOffsets[from], csr.outOffsets[from+1]
+	targets := csr.outTargets[start:end]
+	i := sort.Search(len(targets), func(i int) bool { return targets[i] >= to })
+	return start + uint32(i), i < len(targets) && targets[i] == to
+}
+
+// NumNodes returns the amount of nodes in the graph.
+func (csr *CSRGraph) NumNodes() int {
+	return len(csr.ids)
+}
+
+// NumEdges returns the amount of edges in the graph.
+func (csr *CSRGraph) NumEdges() int {
+	return len(csr.outTargets)
+}
+
+func (csr *CSRGraph) Node(id int64) graph.Node {
+	if _, ok := csr.position(id); !ok {
+		return nil
+	}
+	return simple.Node(id)
+}
+
+func (csr *CSRGraph) Nodes() graph.Nodes {
+	return &csrNodes{ids: csr.ids, all: true}
+}
+
+func (csr *CSRGraph) From(id int64) graph.Nodes {
+	i, ok := csr.position(id)
+	if !ok {
+		return graph.Empty
+	}
+	return &csrNodes{ids: csr.ids, positions: csr.outTargets[csr.outOffsets[i]:csr.outOffsets[i+1]]}
+}
+
+func (csr *CSRGraph) To(id int64) graph.Nodes {
+	i, ok := csr.position(id)
+	if !ok {
+		return graph.Empty
+	}
+	return &csrNodes{ids: csr.ids, positions: csr.inSources[csr.inOffsets[i]:csr.inOffsets[i+1]]}
+}
+
+func (csr *CSRGraph) HasEdgeFromTo(uid, vid int64) bool {
+	from, ok := csr.position(uid)
+	if !ok {
+		return false
+	}
+	to, ok := csr.position(vid)
+	if !ok {
+		return false
+	}
+	_, ok = csr.edgePosition(from, to)
+	return ok
+}
+
+func (csr *CSRGraph) HasEdgeBetween(xid, yid int64) bool {
+	return csr.HasEdgeFromTo(xid, yid) || csr.HasEdgeFromTo(yid, xid)
+}
+
+func (csr *CSRGraph) Edge(uid, vid int64) graph.Edge {
+	from, ok := csr.position(uid)
+	if !ok {
+		return nil
+	}
+	to, ok := csr.position(vid)
+	if !ok {
+		return nil
+	}
+	edge, ok := csr.edgePosition(from, to)
+	if !ok {
+		return nil
+	}
+	valid := Validity{From: csr.validFrom[edge].time(), Until: csr.validUntil[edge].time()}
+	return CSREdge{FId: uid, TId: vid, Kind: csr.kinds[csr.outKinds[edge]], Valid: valid}
+}
+
+// csrNodes iterates over the nodes of a CSRGraph at the positions, or over all of its nodes.
+type csrNodes struct {
+	ids       []int64
+	positions []uint32
+	all       bool
+	current   int // The index of the current node plus one, so that the zero value is before the first node
+}
+
+func (it *csrNodes) length() int {
+	if it.all {
+		return len(it.ids)
+	}
+	return len(it.positions)
+}
+
+func (it *csrNodes) Next() bool {
+	if it.current >= it.length() {
+		return false
+	}
+	it.current++
+	return true
+}
+
+func (it *csrNodes) Len() int {
+	return it.length() - it.current
+}
+
+func (it *csrNodes) Reset() {
+	it.current = 0
+}
+
+func (it *csrNodes) Node() graph.Node {
+	if it.current == 0 || it.current > it.length() {
+		return nil
+	}
+	if it.all {
+		return simple.Node(it.ids[it.current-1])
+	}
+	return simple.Node(it.ids[it.positions[it.current-1]])
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/network"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// exactPageRank ranks the nodes until they converge far beyond the tolerance of PageRank, which is reached after a
+// different amount of iterations depending on the order of the nodes, so that the ranks of two graphs can be compared.
+func exactPageRank(g graph.Directed) map[int64]float64 {
+	return network.PageRank(g, 0.85, 1e-8)
+}
+
+func nodeIDs(nodes graph.Nodes) map[int64]bool {
+	ids := make(map[int64]bool)
+	for nodes.Next() {
+		ids[nodes.Node().ID()] = true
+	}
+	return ids
+}
+
+func sameNodes(a, b map[int64]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range a {
+		if !b[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCSRGraphMatchesSource(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	source, _, _, _, diagnostics, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr := NewCSRGraph(source)
+
+	if csr.NumNodes() != source.Nodes().Len() || csr.NumEdges() != diagnostics.Edges {
+		t.Errorf("Expected %d nodes and %d edges, got %d and %d", source.Nodes().Len(), diagnostics.Edges, csr.NumNodes(), csr.NumEdges())
+	}
+	if !sameNodes(nodeIDs(csr.Nodes()), nodeIDs(source.Nodes())) {
+		t.Error("Expected the graph to have the same nodes")
+	}
+	nodes := source.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		if csr.Node(id) == nil {
+			t.Errorf("Expected node %d", id)
+		}
+		if !sameNodes(nodeIDs(csr.From(id)), nodeIDs(source.From(id))) {
+			t.Errorf("Expected node %d to have the same dependencies", id)
+		}
+		if !sameNodes(nodeIDs(csr.To(id)), nodeIDs(source.To(id))) {
+			t.Errorf("Expected node %d to have the same dependents", id)
+		}
+		from := source.From(id)
+		for from.Next() {
+			to := from.Node().ID()
+			edge := csr.Edge(id, to)
+			if edge == nil || edge.From().ID() != id || edge.To().ID() != to || !csr.HasEdgeFromTo(id, to) || !csr.HasEdgeBetween(to, id) {
+				t.Errorf("Expected an edge from %d to %d", id, to)
+			}
+			if !source.HasEdgeFromTo(to, id) && csr.HasEdgeFromTo(to, id) {
+				t.Errorf("Unexpected edge from %d to %d", to, id)
+			}
+		}
+	}
+
+	expected, actual := exactPageRank(source), exactPageRank(csr)
+	for id, rank := range expected {
+		if math.Abs(actual[id]-rank) > 1e-6 {
+			t.Errorf("Expected node %d to have rank %f, got %f", id, rank, actual[id])
+		}
+	}
+}
+
+func TestCSRGraph(t *testing.T) {
+	source := simple.NewDirectedGraph()
+	for _, id := range []int64{7, 3, 12, 5} {
+		source.AddNode(simple.Node(id))
+	}
+	date := func(year int) time.Time {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	source.SetEdge(GraphEdge{FId: 7, TId: 3, g: source})
+	source.SetEdge(GraphEdge{FId: 7, TId: 12, Kind: "dev", Valid: Validity{From: date(2020), Until: date(2021)}, g: source})
+	source.SetEdge(GraphEdge{FId: 12, TId: 3, Kind: "build", Valid: Validity{From: date(2019)}, g: source})
+	// Times outside of the years 1678 to 2262 do not fit in Unix nanoseconds, and the Unix epoch is not a zero time
+	source.SetEdge(GraphEdge{FId: 3, TId: 5, Valid: Validity{From: date(1600), Until: date(3000)}, g: source})
+	source.SetEdge(GraphEdge{FId: 12, TId: 5, Valid: Validity{From: time.Unix(0, 0).UTC()}, g: source})
+	csr := NewCSRGraph(source)
+
+	for _, c := range []struct {
+		from, to int64
+		kind     string
+		valid    Validity
+	}{
+		{7, 3, "", Validity{}},
+		{7, 12, "dev", Validity{From: date(2020), Until: date(2021)}},
+		{12, 3, "build", Validity{From: date(2019)}},
+		{3, 5, "", Validity{From: date(1600), Until: date(3000)}},
+		{12, 5, "", Validity{From: time.Unix(0, 0).UTC()}},
+	} {
+		edge, ok := csr.Edge(c.from, c.to).(CSREdge)
+		if !ok || edge.Kind != c.kind || !edge.Valid.From.Equal(c.valid.From) || !edge.Valid.Until.Equal(c.valid.Until) {
+			t.Errorf("Expected an edge from %d to %d of kind %q valid in %+v, got %+v", c.from, c.to, c.kind, c.valid, edge)
+		}
+	}
+	if valid := nodeIDs(ValidView(csr, date(2021), date(2022)).From(7)); len(valid) != 0 {
+		t.Errorf("Expected the edges from node 7 not to be current in 2021, got %v", valid)
+	}
+	if valid := nodeIDs(ValidView(csr, date(2020), date(2020)).To(3)); !sameNodes(valid, map[int64]bool{12: true}) {
+		t.Errorf("Expected only node 12 to depend on node 3 in 2020, got %v", valid)
+	}
+	if valid := nodeIDs(ValidView(csr, date(2500), date(2500)).To(5)); !sameNodes(valid, map[int64]bool{3: true, 12: true}) {
+		t.Errorf("Expected nodes 3 and 12 to depend on node 5 in 2500, got %v", valid)
+	}
+	if csr.Edge(3, 7) != nil || csr.HasEdgeFromTo(3, 7) || !csr.HasEdgeBetween(3, 7) || csr.HasEdgeBetween(5, 7) {
+		t.Error("Expected edges to only exist in their own direction")
+	}
+
+	if csr.Node(4) != nil || csr.From(4).Len() != 0 || csr.To(4).Len() != 0 || csr.HasEdgeFromTo(4, 3) {
+		t.Error("Expected a node that is not in the graph to have no edges")
+	}
+	if from := csr.From(5); from.Len() != 0 || from.Next() {
+		t.Error("Expected node 5 to have no dependencies")
+	}
+
+	to := csr.To(3)
+	if to.Len() != 2 {
+		t.Errorf("Expected 2 dependents of node 3, got %d", to.Len())
+	}
+	to.Next()
+	if to.Len() != 1 {
+		t.Errorf("Expected 1 remaining dependent, got %d", to.Len())
+	}
+	to.Reset()
+	if ids := nodeIDs(to); !sameNodes(ids, map[int64]bool{7: true, 12: true}) {
+		t.Errorf("Expected nodes 7 and 12 to depend on node 3, got %v", ids)
+	}
+
+	empty := NewCSRGraph(simple.NewDirectedGraph())
+	if empty.NumNodes() != 0 || empty.Nodes().Next() || empty.Node(0) != nil {
+		t.Error("Expected an empty graph")
+	}
+}
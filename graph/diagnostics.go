@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IssueKind is a reason for a declared dependency to produce fewer edges than it should.
+type IssueKind int
+
+const (
+	// UnparsableConstraint is a dependency constraint the ecosystem could not parse.
+	UnparsableConstraint IssueKind = iota
+	// UnknownDependency is a dependency on a package that is not in the dataset.
+	UnknownDependency
+	// UnmatchedConstraint is a constraint that none of the versions of the dependency satisfies.
+	UnmatchedConstraint
+	// UnparsableVersion is a version of a package that the ecosystem could not parse, so no constraint can match it.
+	UnparsableVersion
+	// SelfLoop is an edge from a package version to itself, which is left out of the graph.
+	SelfLoop
+	// UnparsableTimestamp is a timestamp of a package version that the ecosystem could not parse. The version is
+	// still added to the graph, but it lies outside of every time interval.
+	UnparsableTimestamp
+)
+
+// IssueKinds lists every kind of issue in the order they are reported.
+var IssueKinds = []IssueKind{UnparsableConstraint, UnknownDependency, UnmatchedConstraint, UnparsableVersion, SelfLoop, UnparsableTimestamp}
+
+var issueKindNames = []string{"unparsable_constraint", "unknown_dependency", "unmatched_constraint", "unparsable_version", "self_loop", "unparsable_timestamp"}
+
+func (k IssueKind) String() string {
+	if k < 0 || int(k) >= len(issueKindNames) {
+		return fmt.Sprintf("IssueKind(%d)", int(k))
+	}
+	return issueKindNames[k]
+}
+
+func (k IssueKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *IssueKind) UnmarshalText(text []byte) error {
+	for i, name := range issueKindNames {
+		if name == string(text) {
+			*k = IssueKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown issue kind %q", text)
+}
+
+// Issue is a single occurrence of an issue. For unparsable versions, Package and Version name the version that could
+// not be parsed and the dependency fields are empty.
+type Issue struct {
+	Kind       IssueKind `json:"kind"`
+	Package    string    `json:"package"`
+	Version    string    `json:"version"`
+	Dependency string    `json:"dependency,omitempty"`
+	Constraint string    `json:"constraint,omitempty"`
+	Detail     string    `json:"detail,omitempty"` // The parse error, if there is one
+}
+
+// IssueSummary counts the issues of one kind and keeps the first few of them as examples.
+type IssueSummary struct {
+	Kind     IssueKind `json:"kind"`
+	Count    int       `json:"count"`
+	Examples []Issue   `json:"examples"`
+}
+
+// DefaultMaxExamples is the amount of examples kept per kind of issue by CreateEdges.
+const DefaultMaxExamples = 10
+
+// Diagnostics reports how much of the declared dependencies made it into the graph and why the rest did not. It is
+// filled by CreateEdges.
+type Diagnostics struct {
+	Ecosystem    string         `json:"ecosystem"`
+	Dependencies int            `json:"dependencies"` // Declared dependencies, over all versions of all packages
+	Edges        int            `json:"edges"`
+	Issues       []IssueSummary `json:"issues"` // One summary per kind, in the order of IssueKinds
+	Cache        CacheStats     `json:"cache"`
+	MaxExamples  int            `json:"-"`
+
+	unparsableVersions map[string]bool // Every version is only reported once, however many dependents it has
+}
+
+// NewDiagnostics creates an empty report that keeps at most maxExamples examples per kind of issue.
+func NewDiagnostics(maxExamples int) *Diagnostics {
+	d := &Diagnostics{MaxExamples: maxExamples, unparsableVersions: make(map[string]bool)}
+	for _, kind := range IssueKinds {
+		d.Issues = append(d.Issues, IssueSummary{Kind: kind, Examples: []Issue{}})
+	}
+	return d
+}
+
+func (d *Diagnostics) record(issue Issue) {
+	if issue.Kind == UnparsableVersion {
+		key := issue.Package + "\x00" + issue.Version
+		if d.unparsableVersions[key] {
+			return
+		}
+		d.unparsableVersions[key] = true
+	}
+	summary := &d.Issues[issue.Kind]
+	summary.Count++
+	if len(summary.Examples) < d.MaxExamples {
+		summary.Examples = append(summary.Examples, issue)
+	}
+}
+
+// PackageVersion returns the package version the issue is about.
+func (d *Diagnostics) PackageVersion(issue Issue) PackageVersion {
+	return PackageVersion{Ecosystem: d.Ecosystem, Name: issue.Package, Version: issue.Version}
+}
+
+// DependencyPackage returns the dependency the issue is about, as a package without a version, or false if the issue
+// is not about a dependency. The name of the dependency is normalised, since it is written as it was declared.
+func (d *Diagnostics) DependencyPackage(issue Issue) (PackageVersion, bool) {
+	if issue.Dependency == "" {
+		return PackageVersion{}, false
+	}
+	name := issue.Dependency
+	if ecosystem, ok := LookupEcosystem(d.Ecosystem); ok {
+		name = ecosystem.NormalizeName(name)
+	}
+	return PackageVersion{Ecosystem: d.Ecosystem, Name: name}, true
+}
+
+// Count returns the amount of issues of a kind.
+func (d *Diagnostics) Count(kind IssueKind) int {
+	return d.Issues[kind].Count
+}
+
+// String summarises the report in a few lines.
+func (d *Diagnostics) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d declared dependencies resulted in %d edges\n", d.Dependencies, d.Edges)
+	for _, summary := range d.Issues {
+		if summary.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %d", summary.Kind, summary.Count)
+		if len(summary.Examples) > 0 {
+			example := summary.Examples[0]
+			fmt.Fprintf(&b, " (e.g. %s %s", example.Package, example.Version)
+			if example.Dependency != "" {
+				fmt.Fprintf(&b, " -> %s %s", example.Dependency, example.Constraint)
+			}
+			b.WriteString(")")
+		}
+		b.WriteString("\n")
+	}
+	if d.Cache.Lookups > 0 {
+		fmt.Fprintf(&b, "Resolution cache: %s\n", d.Cache)
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestCreateEdgesDiagnostics(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-01-01T00:00:00Z",
+					Dependencies: map[string]string{
+						"lib":     "^1.0.0",
+						"broken":  "not a range",
+						"missing": "^1.0.0",
+						"old":     ">=2.0.0",
+						"app":     "*",
+					},
+				},
+			},
+		},
+		{
+			Name: "lib",
+			Versions: map[string]VersionInfo{
+				"1.0.0":   {Timestamp: "2020-01-01T00:00:00Z"},
+				"1.1.0":   {Timestamp: "2020-06-01T00:00:00Z"},
+				"release": {Timestamp: "2020-07-01T00:00:00Z"},
+			},
+		},
+		{
+			Name:     "old",
+			Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2019-01-01T00:00:00Z"}},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	diagnostics := CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, NPM)
+
+	if diagnostics.Dependencies != 5 || diagnostics.Edges != 2 {
+		t.Errorf("Expected 5 dependencies and 2 edges, got %d and %d", diagnostics.Dependencies, diagnostics.Edges)
+	}
+	expected := map[IssueKind]int{
+		UnparsableConstraint: 1,
+		UnknownDependency:    1,
+		UnmatchedConstraint:  1,
+		UnparsableVersion:    1,
+		SelfLoop:             1,
+	}
+	for kind, count := range expected {
+		if diagnostics.Count(kind) != count {
+			t.Errorf("Expected %d issues of kind %s, got %d", count, kind, diagnostics.Count(kind))
+		}
+	}
+	if example := diagnostics.Issues[UnknownDependency].Examples[0]; example.Dependency != "missing" || example.Package != "app" {
+		t.Errorf("Unexpected example %+v", example)
+	}
+	if example := diagnostics.Issues[UnparsableVersion].Examples[0]; example.Package != "lib" || example.Version != "release" {
+		t.Errorf("Unexpected example %+v", example)
+	}
+}
+
+func TestCreateGraphReportsUnparsableTimestamps(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-04-22T20:15:37", Author: "Someone", License: "MIT"},
+				"2.0.0": {Timestamp: "sometime in 2022"},
+			},
+		},
+	}
+	_, nodes, nodeInfo, _, diagnostics, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics.Count(UnparsableTimestamp) != 1 {
+		t.Fatalf("Expected 1 unparsable timestamp, got %d", diagnostics.Count(UnparsableTimestamp))
+	}
+	if example := diagnostics.Issues[UnparsableTimestamp].Examples[0]; example.Version != "2.0.0" || example.Detail == "" {
+		t.Errorf("Unexpected example %+v", example)
+	}
+
+	valid := nodeInfo[nodeId(t, nodes, "pkg:maven/app@1.0.0")]
+	if !valid.Timestamp.Equal(time.Date(2021, 4, 22, 20, 15, 37, 0, time.UTC)) {
+		t.Errorf("Unexpected timestamp %v", valid.Timestamp)
+	}
+	if valid.Metadata == nil || valid.Metadata.Author != "Someone" || valid.Metadata.License != "MIT" {
+		t.Errorf("Unexpected metadata %+v", valid.Metadata)
+	}
+	invalid := nodeInfo[nodeId(t, nodes, "pkg:maven/app@2.0.0")]
+	if !invalid.Timestamp.IsZero() || invalid.Metadata != nil {
+		t.Errorf("Expected a zero timestamp and no metadata, got %v and %+v", invalid.Timestamp, invalid.Metadata)
+	}
+}
+
+func TestDiagnosticsExamplesAreCapped(t *testing.T) {
+	diagnostics := NewDiagnostics(2)
+	for i := 0; i < 5; i++ {
+		diagnostics.record(Issue{Kind: UnknownDependency, Package: "app", Version: "1.0.0", Dependency: "missing"})
+		diagnostics.record(Issue{Kind: UnparsableVersion, Package: "lib", Version: "release"})
+	}
+	if diagnostics.Count(UnknownDependency) != 5 || len(diagnostics.Issues[UnknownDependency].Examples) != 2 {
+		t.Errorf("Expected 5 issues and 2 examples, got %+v", diagnostics.Issues[UnknownDependency])
+	}
+	if diagnostics.Count(UnparsableVersion) != 1 {
+		t.Errorf("Expected an unparsable version to be reported once, got %d", diagnostics.Count(UnparsableVersion))
+	}
+}
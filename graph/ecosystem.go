@@ -0,0 +1,306 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
+// Version is a parsed version of a package.
+type Version interface {
+	// Compare returns -1, 0 or 1 when the version is lower than, equal to or higher than other, which has to be a
+	// version of the same ecosystem.
+	Compare(other Version) int
+	String() string
+}
+
+// Constraint is a parsed dependency constraint that the versions of the dependency are checked against.
+type Constraint interface {
+	Check(v Version) bool
+}
+
+// Ecosystem determines how the names, versions, version constraints and timestamps of the packages of a package
+// manager are interpreted. Supporting a new package manager comes down to implementing Ecosystem and registering it
+// with RegisterEcosystem.
+type Ecosystem interface {
+	// Name is the name of the ecosystem as shown to users and used in package URLs, e.g. "npm" or "maven".
+	Name() string
+	// NormalizeName returns the canonical form of a package name, so that dependencies written differently from the
+	// name of the package they refer to still find it.
+	NormalizeName(name string) string
+	ParseVersion(version string) (Version, error)
+	// ParseConstraint parses a dependency constraint the way the package manager writes them.
+	ParseConstraint(constraint string) (Constraint, error)
+	// ParseTimestamp parses the release timestamp of a version in the formats the package manager publishes them.
+	ParseTimestamp(timestamp string) (time.Time, error)
+	// PurlName returns the namespace and name of the package URL of a package, such as org.example and app for the
+	// Maven package org.example:app. The namespace is empty if the package has none.
+	PurlName(name string) (namespace, purlName string)
+	// ParsePurlName returns the name of the package that the namespace and name of a package URL refer to, in the
+	// form NormalizeName returns it.
+	ParsePurlName(namespace, purlName string) (string, error)
+}
+
+// Picker is implemented by the ecosystems of package managers that do not always resolve a dependency to the highest
+// version that matches its constraint.
+type Picker interface {
+	// Pick returns the position among the candidates, the versions that match the constraint, of the version that the
+	// dependency resolves to once it has been published, or -1 if it resolves to the highest published candidate.
+	Pick(constraint string, candidates []Version) int
+}
+
+// Every supported ecosystem, in the order they are offered to users.
+var (
+	NPM   Ecosystem = npmEcosystem{}
+	Maven Ecosystem = mavenEcosystem{}
+	PyPI  Ecosystem = pypiEcosystem{}
+	Cargo Ecosystem = cargoEcosystem{}
+	Go    Ecosystem = goEcosystem{}
+)
+
+var ecosystems = []Ecosystem{NPM, Maven, PyPI, Cargo, Go}
+
+// RegisterEcosystem makes an ecosystem available to users. It panics if an ecosystem with the same name already
+// exists.
+func RegisterEcosystem(ecosystem Ecosystem) {
+	if _, ok := LookupEcosystem(ecosystem.Name()); ok {
+		panic("graph: ecosystem " + ecosystem.Name() + " is registered twice")
+	}
+	ecosystems = append(ecosystems, ecosystem)
+}
+
+// Ecosystems returns every registered ecosystem.
+func Ecosystems() []Ecosystem {
+	return append([]Ecosystem(nil), ecosystems...)
+}
+
+// LookupEcosystem finds a registered ecosystem by its name, ignoring case.
+func LookupEcosystem(name string) (Ecosystem, bool) {
+	for _, ecosystem := range ecosystems {
+		if strings.EqualFold(ecosystem.Name(), name) {
+			return ecosystem, true
+		}
+	}
+	return nil, false
+}
+
+// compareVersions compares the versions a and b of a package of the ecosystem the way Version.Compare does.
+func compareVersions(ecosystem Ecosystem, a, b string) int {
+	versionA, err := ecosystem.ParseVersion(a)
+	if err != nil {
+		versionA = nil
+	}
+	versionB, err := ecosystem.ParseVersion(b)
+	if err != nil {
+		versionB = nil
+	}
+	return compareParsedVersions(versionA, versionB, a, b)
+}
+
+// compareParsedVersions compares the versions a and b, parsed into versionA and versionB or nil if the ecosystem cannot
+// parse them. Versions the ecosystem can parse are higher than versions it cannot, which are compared as strings.
+func compareParsedVersions(versionA, versionB Version, a, b string) int {
+	switch {
+	case versionA != nil && versionB != nil:
+		return versionA.Compare(versionB)
+	case versionA != nil:
+		return 1
+	case versionB != nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// commonTimestampLayouts are tried by every ecosystem after its own layouts, so that timestamps written by other
+// tools, such as the README example 06-05-2022T10:00:01 or the output of time.Time.String, are read as well. Fractional
+// seconds are accepted by every layout with seconds.
+var commonTimestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 -0700 MST",
+	"2006-01-02",
+	"20060102150405",
+	"02-01-2006T15:04:05",
+	"02-01-2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.UnixDate,
+}
+
+// parseTimestamp parses a timestamp in the first of the layouts that fits it, or else in the first of the common
+// layouts that does. A timestamp of 10 or 13 digits is read as Unix time in seconds or milliseconds. Timestamps without
+// a zone are in UTC, and every timestamp is returned in UTC, so that timestamps from different sources compare equal.
+func parseTimestamp(timestamp string, layouts ...string) (time.Time, error) {
+	timestamp = strings.TrimSpace(timestamp)
+	if timestamp == "" {
+		return time.Time{}, errors.New("missing timestamp")
+	}
+	for _, layout := range append(layouts, commonTimestampLayouts...) {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if unix, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		switch len(timestamp) {
+		case 10:
+			return time.Unix(unix, 0).UTC(), nil
+		case 13:
+			return time.UnixMilli(unix).UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+}
+
+// semverVersion is a version of the ecosystems that use semantic versioning.
+type semverVersion struct {
+	*semver.Version
+}
+
+func parseSemverVersion(version string) (Version, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return semverVersion{v}, nil
+}
+
+func (v semverVersion) Compare(other Version) int {
+	return v.Version.Compare(other.(semverVersion).Version)
+}
+
+func (v semverVersion) String() string {
+	return v.Original()
+}
+
+type semverConstraint struct {
+	constraint *semver.Constraints
+}
+
+func parseSemverConstraint(constraint string) (Constraint, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+	}
+	return semverConstraint{c}, nil
+}
+
+func (c semverConstraint) Check(v Version) bool {
+	version, ok := v.(semverVersion)
+	return ok && c.constraint.Check(version.Version)
+}
+
+// npmEcosystem uses the semantic versioning and range syntax of npm. It is the default for data of unknown origin.
+type npmEcosystem struct{}
+
+func (npmEcosystem) Name() string {
+	return "npm"
+}
+
+func (npmEcosystem) NormalizeName(name string) string {
+	return name
+}
+
+func (npmEcosystem) ParseVersion(version string) (Version, error) {
+	return parseSemverVersion(version)
+}
+
+func (npmEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	return parseSemverConstraint(constraint)
+}
+
+func (npmEcosystem) ParseTimestamp(timestamp string) (time.Time, error) {
+	return parseTimestamp(timestamp, time.RFC3339, "2006-01-02T15:04:05")
+}
+
+// PurlName uses the scope of a scoped package, such as @babel for @babel/core, as namespace.
+func (npmEcosystem) PurlName(name string) (string, string) {
+	if i := strings.Index(name, "/"); strings.HasPrefix(name, "@") && i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// ParsePurlName accepts scopes with and without their @. Names are kept as they are, since the registry still has
+// packages from before names had to be lowercase.
+func (npmEcosystem) ParsePurlName(namespace, name string) (string, error) {
+	if namespace == "" {
+		return name, nil
+	}
+	if strings.Contains(namespace, "/") {
+		return "", fmt.Errorf("npm scope %q has more than one segment", namespace)
+	}
+	return "@" + strings.TrimPrefix(namespace, "@") + "/" + name, nil
+}
+
+// goEcosystem uses minimal version selection, so a module version only depends on exactly the versions it requires.
+type goEcosystem struct{}
+
+func (goEcosystem) Name() string {
+	return "golang"
+}
+
+func (goEcosystem) NormalizeName(name string) string {
+	return name
+}
+
+func (goEcosystem) ParseVersion(version string) (Version, error) {
+	return parseSemverVersion(version)
+}
+
+func (goEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	return exactConstraint(constraint), nil
+}
+
+func (goEcosystem) ParseTimestamp(timestamp string) (time.Time, error) {
+	return parseTimestamp(timestamp, time.RFC3339)
+}
+
+// PurlName uses the module path up to its last element as namespace, so github.com/BurntSushi/toml has namespace
+// github.com/BurntSushi and name toml. Module paths are case-sensitive, so they are not lowercased.
+func (goEcosystem) PurlName(name string) (string, string) {
+	return splitPurlPath(name)
+}
+
+func (goEcosystem) ParsePurlName(namespace, name string) (string, error) {
+	return joinPurlPath(namespace, name), nil
+}
+
+// splitPurlPath splits a name at its last slash into the namespace and name of a package URL.
+func splitPurlPath(name string) (string, string) {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// joinPurlPath joins the namespace and name of a package URL with a slash, the opposite of splitPurlPath.
+func joinPurlPath(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// withoutPurlNamespace returns the name of a package URL of an ecosystem that has no namespaces.
+func withoutPurlNamespace(ecosystem Ecosystem, namespace, name string) (string, error) {
+	if namespace != "" {
+		return "", fmt.Errorf("%s packages have no namespace, got %q", ecosystem.Name(), namespace)
+	}
+	return ecosystem.NormalizeName(name), nil
+}
+
+// exactConstraint is only satisfied by the version it names, written the same way.
+type exactConstraint string
+
+func (c exactConstraint) Check(v Version) bool {
+	return v.String() == string(c)
+}
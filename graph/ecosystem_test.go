@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestCreateEdgesGo(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "example.com/app",
+			Versions: map[string]VersionInfo{
+				"v1.0.0": {Timestamp: "2022-01-01T00:00:00Z", Dependencies: map[string]string{"golang.org/x/text": "v0.3.7"}},
+			},
+		},
+		{
+			Name: "golang.org/x/text",
+			Versions: map[string]VersionInfo{
+				"v0.3.6": {Timestamp: "2021-01-01T00:00:00Z"},
+				"v0.3.7": {Timestamp: "2021-06-01T00:00:00Z"},
+				"v0.3.8": {Timestamp: "2022-06-01T00:00:00Z"},
+			},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, Go)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, Go)
+
+	from := nodeId(t, nodeIndex, "pkg:golang/example.com/app@v1.0.0")
+	if edges := graph.From(from).Len(); edges != 1 {
+		t.Errorf("Expected 1 edge, got %d", edges)
+	}
+	if graph.Edge(from, nodeId(t, nodeIndex, "pkg:golang/golang.org/x/text@v0.3.7")) == nil {
+		t.Error("Expected an edge to exactly the required version")
+	}
+}
+
+func TestLookupEcosystem(t *testing.T) {
+	for _, ecosystem := range Ecosystems() {
+		if found, ok := LookupEcosystem(ecosystem.Name()); !ok || found != ecosystem {
+			t.Errorf("Expected to find %s", ecosystem.Name())
+		}
+	}
+	if ecosystem, ok := LookupEcosystem("PyPI"); !ok || ecosystem != PyPI {
+		t.Error("Expected names to be case insensitive")
+	}
+	if _, ok := LookupEcosystem("cpan"); ok {
+		t.Error("Expected no ecosystem called cpan")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering npm twice to panic")
+		}
+	}()
+	RegisterEcosystem(npmEcosystem{})
+}
+
+func TestEcosystemVersionOrdering(t *testing.T) {
+	cases := []struct {
+		ecosystem Ecosystem
+		lower     string
+		higher    string
+	}{
+		{NPM, "1.0.0-rc.1", "1.0.0"},
+		{Cargo, "0.9.10", "0.10.0"},
+		{Go, "v0.0.0-20180917221912-90fa682c2a6e", "v0.1.0"},
+		{PyPI, "1.0rc1", "1.0.post1"},
+	}
+	for _, c := range cases {
+		lower, err1 := c.ecosystem.ParseVersion(c.lower)
+		higher, err2 := c.ecosystem.ParseVersion(c.higher)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("%s: could not parse %s or %s: %v %v", c.ecosystem.Name(), c.lower, c.higher, err1, err2)
+		}
+		if lower.Compare(higher) >= 0 || higher.Compare(lower) <= 0 {
+			t.Errorf("%s: expected %s < %s", c.ecosystem.Name(), c.lower, c.higher)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2021, 4, 22, 20, 15, 37, 0, time.UTC)
+	cases := []struct {
+		ecosystem Ecosystem
+		timestamp string
+	}{
+		{Maven, "2021-04-22T20:15:37"},
+		{Maven, "20210422201537"},
+		{NPM, "2021-04-22T20:15:37.000Z"},
+		{NPM, "2021-04-22T22:15:37+02:00"},
+		{PyPI, "2021-04-22 20:15:37"},
+		{Cargo, "22-04-2021T20:15:37"},
+		{Cargo, " 2021-04-22T20:15:37Z "},
+		{Go, "1619122537"},
+		{Go, "1619122537000"},
+		{Go, "Thu, 22 Apr 2021 20:15:37 GMT"},
+		{Go, "2021-04-22 20:15:37 +0000 UTC"},
+	}
+	for _, c := range cases {
+		actual, err := c.ecosystem.ParseTimestamp(c.timestamp)
+		if err != nil {
+			t.Errorf("%s: could not parse %q: %v", c.ecosystem.Name(), c.timestamp, err)
+			continue
+		}
+		if !actual.Equal(expected) || actual.Location() != time.UTC {
+			t.Errorf("%s: expected %q to be %v, got %v", c.ecosystem.Name(), c.timestamp, expected, actual)
+		}
+	}
+
+	for _, invalid := range []string{"", "yesterday", "2021-13-01", "12345"} {
+		if _, err := NPM.ParseTimestamp(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
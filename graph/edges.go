@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"fmt"
+	"sync"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// edgeBatchSize is the amount of packages a worker of CreateEdgesConcurrent resolves at a time.
+const edgeBatchSize = 64
+
+// edgeBatch holds the edges and issues found for a number of packages, to be added to the graph and diagnostics by a
+// single goroutine.
+type edgeBatch struct {
+	edges        []GraphEdge
+	issues       []Issue
+	dependencies int
+}
+
+// resolveEdges checks the dependencies of the packages against the versions of their dependencies. The cache is safe
+// for concurrent use, so any number of goroutines can call it at the same time.
+func resolveEdges(packages []PackageInfo, nodes *NodeIndex, cache *ResolutionCache) edgeBatch {
+	var batch edgeBatch
+	for _, packageInfo := range packages {
+		for version, dependencyInfo := range packageInfo.Versions {
+			packageGoId, _ := nodes.Lookup(PackageVersion{Ecosystem: cache.ecosystem.Name(), Name: packageInfo.Name, Version: version})
+			for dependencyName, dependencyVersion := range dependencyInfo.Dependencies {
+				batch.dependencies++
+				issue := Issue{Package: packageInfo.Name, Version: version, Dependency: dependencyName, Constraint: dependencyVersion}
+				resolved, versionIssues := cache.resolve(cache.ecosystem.NormalizeName(dependencyName), dependencyVersion)
+				batch.issues = append(batch.issues, versionIssues...)
+				switch {
+				case resolved.err != nil:
+					issue.Kind, issue.Detail = UnparsableConstraint, resolved.err.Error()
+				case !resolved.known:
+					issue.Kind = UnknownDependency
+				case len(resolved.ids) == 0:
+					issue.Kind = UnmatchedConstraint
+				default:
+					kind := dependencyInfo.Kinds[dependencyName]
+					published := cache.nodeInfo[packageGoId].Timestamp
+					for i, dependencyGoId := range resolved.ids {
+						// Ensure that we do not create edges to self because some packages do that...
+						if dependencyGoId == packageGoId {
+							selfLoop := issue
+							selfLoop.Kind = SelfLoop
+							batch.issues = append(batch.issues, selfLoop)
+							continue
+						}
+						valid := newValidity(published, cache.nodeInfo[dependencyGoId].Timestamp, resolved.supersededAt[i])
+						batch.edges = append(batch.edges, GraphEdge{FId: packageGoId, TId: dependencyGoId, Kind: kind, Valid: valid})
+					}
+					continue
+				}
+				batch.issues = append(batch.issues, issue)
+			}
+		}
+	}
+	return batch
+}
+
+// apply adds the edges of the batch to the graph and its issues to the diagnostics.
+func (batch edgeBatch) apply(graph *simple.DirectedGraph, diagnostics *Diagnostics) {
+	diagnostics.Dependencies += batch.dependencies
+	for _, edge := range batch.edges {
+		if !graph.HasEdgeFromTo(edge.FId, edge.TId) {
+			edge.g = graph
+			graph.SetEdge(edge)
+			diagnostics.Edges++
+		}
+	}
+	for _, issue := range batch.issues {
+		diagnostics.record(issue)
+	}
+}
+
+// printProgress prints how many of the packages have been connected to their dependencies, every time the index of
+// a finished package is sent on the returned channel. The channel has to be closed when all packages are done.
+func printProgress(packagesLength int) chan<- int {
+	channel := make(chan int, 2)
+	go func(n int, ch chan int) {
+		for i := range ch {
+			fmt.Fprintf(Progress, "\u001b[1A \u001b[2K \r") // Clear the last line
+			fmt.Fprintf(Progress, "%.2f%% done (%d / %d packages connected to their dependencies)\n", float64(i)/float64(n)*100, i, n)
+		}
+	}(packagesLength, channel)
+	return channel
+}
+
+// CreateEdgesConcurrent creates the same edges and diagnostics as CreateEdges, but resolves the dependencies with the
+// given amount of worker goroutines, which share one ResolutionCache. The workers only read the maps and send the edges
+// they find in batches to the calling goroutine, which is the only one that writes to the graph. The examples in the
+// diagnostics can differ between runs, since the order in which batches arrive does.
+func CreateEdgesConcurrent(graph *simple.DirectedGraph, inputList *[]PackageInfo, nodes *NodeIndex, nodeInfoMap map[int64]NodeInfo, versions *VersionIndex, ecosystem Ecosystem, workers int) *Diagnostics {
+	if workers < 1 {
+		workers = 1
+	}
+	packages := *inputList
+	cache := NewResolutionCache(ecosystem, nodes, nodeInfoMap, versions)
+	jobs := make(chan int)
+	batches := make(chan edgeBatch, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := start + edgeBatchSize
+				if end > len(packages) {
+					end = len(packages)
+				}
+				batches <- resolveEdges(packages[start:end], nodes, cache)
+			}
+		}()
+	}
+	go func() {
+		for start := 0; start < len(packages); start += edgeBatchSize {
+			jobs <- start
+		}
+		close(jobs)
+		wg.Wait()
+		close(batches)
+	}()
+
+	diagnostics := NewDiagnostics(DefaultMaxExamples)
+	progress := printProgress(len(packages))
+	done := 0
+	for batch := range batches {
+		batch.apply(graph, diagnostics)
+		done += edgeBatchSize
+		if done > len(packages) {
+			done = len(packages)
+		}
+		progress <- done
+	}
+	close(progress)
+	diagnostics.Ecosystem = ecosystem.Name()
+	diagnostics.Cache = cache.Stats()
+	return diagnostics
+}
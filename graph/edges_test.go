@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// edgeSet returns the edges of the graph as from -> to strings with their kind.
+func edgeSet(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo) map[string]bool {
+	edges := make(map[string]bool)
+	it := g.Edges()
+	for it.Next() {
+		edge := it.Edge().(GraphEdge)
+		from, to := nodeMap[edge.FId], nodeMap[edge.TId]
+		edges[fmt.Sprintf("%s-%s -> %s-%s (%s)", from.Name, from.Version, to.Name, to.Version, edge.Kind)] = true
+	}
+	return edges
+}
+
+// randomPackages generates packages with random npm dependencies, including some that cannot be resolved.
+func randomPackages(amount int) []PackageInfo {
+	random := rand.New(rand.NewSource(42))
+	constraints := []string{"^1.0.0", "~1.1.0", ">=1.2.0", "*", "1.0.0", "<1.1.0 || >=2.0.0", "not a range"}
+	packages := make([]PackageInfo, amount)
+	for i := range packages {
+		packages[i] = PackageInfo{Name: fmt.Sprintf("package-%d", i), Versions: make(map[string]VersionInfo)}
+		for _, version := range []string{"1.0.0", "1.1.0", "1.2.0", "2.0.0"}[:1+random.Intn(4)] {
+			dependencies := make(map[string]string)
+			kinds := make(map[string]string)
+			for d := random.Intn(6); d > 0; d-- {
+				name := fmt.Sprintf("package-%d", random.Intn(amount+amount/10)) // Some do not exist
+				dependencies[name] = constraints[random.Intn(len(constraints))]
+				if random.Intn(5) == 0 {
+					kinds[name] = "dev"
+				}
+			}
+			packages[i].Versions[version] = VersionInfo{Timestamp: "2021-01-01T00:00:00Z", Dependencies: dependencies, Kinds: kinds}
+		}
+	}
+	return packages
+}
+
+func TestCreateEdgesConcurrentMatchesSequential(t *testing.T) {
+	datasets := map[string]struct {
+		packages  []PackageInfo
+		ecosystem Ecosystem
+	}{
+		"test_data.json": {ParseJSON("../data/input/test_data.json"), Maven},
+		"random":         {randomPackages(1000), NPM},
+	}
+	for name, dataset := range datasets {
+		t.Run(name, func(t *testing.T) {
+			sequential := simple.NewDirectedGraph()
+			nodeIndex, nodeMap := CreateMaps(&dataset.packages, sequential, dataset.ecosystem)
+			versionIndex := CreateVersionIndex(&dataset.packages)
+			expected := CreateEdges(sequential, &dataset.packages, nodeIndex, nodeMap, versionIndex, dataset.ecosystem)
+			expectedEdges := edgeSet(sequential, nodeMap)
+			if len(expectedEdges) == 0 {
+				t.Fatal("Expected the dataset to have edges")
+			}
+
+			for _, workers := range []int{1, 2, 8} {
+				concurrent := simple.NewDirectedGraph()
+				nodeIndex, nodeMap := CreateMaps(&dataset.packages, concurrent, dataset.ecosystem)
+				diagnostics := CreateEdgesConcurrent(concurrent, &dataset.packages, nodeIndex, nodeMap, versionIndex, dataset.ecosystem, workers)
+				edges := edgeSet(concurrent, nodeMap)
+				if len(edges) != len(expectedEdges) {
+					t.Errorf("%d workers: expected %d edges, got %d", workers, len(expectedEdges), len(edges))
+				}
+				for edge := range expectedEdges {
+					if !edges[edge] {
+						t.Errorf("%d workers: missing edge %s", workers, edge)
+					}
+				}
+				if diagnostics.Dependencies != expected.Dependencies || diagnostics.Edges != expected.Edges {
+					t.Errorf("%d workers: expected %d dependencies and %d edges, got %d and %d", workers,
+						expected.Dependencies, expected.Edges, diagnostics.Dependencies, diagnostics.Edges)
+				}
+				for _, kind := range IssueKinds {
+					if diagnostics.Count(kind) != expected.Count(kind) {
+						t.Errorf("%d workers: expected %d issues of kind %s, got %d", workers, expected.Count(kind), kind, diagnostics.Count(kind))
+					}
+				}
+			}
+		})
+	}
+}
@@ -2,17 +2,11 @@ package graph
 
 import (
 	"fmt"
-	"hash/crc32"
-	"hash/crc64"
+	"io"
 	"log"
 	"os"
-	"regexp"
-	"runtime"
-	"strings"
-	"sync"
 	"time"
 
-	"github.com/Masterminds/semver"
 	"github.com/mailru/easyjson"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/encoding/dot"
@@ -24,6 +18,22 @@ import (
 type VersionInfo struct {
 	Dependencies map[string]string `json:"dependencies"`
 	Timestamp    string            `json:"timestamp"`
+	// Kinds holds the kind of the dependencies that are not regular dependencies, such as "dev" or "build"
+	Kinds map[string]string `json:"kinds,omitempty"`
+	// The optional metadata of the version, as far as the input provides it
+	Author      string `json:"author,omitempty"`
+	License     string `json:"license,omitempty"`
+	Repository  string `json:"repository,omitempty"` // The URL of the source repository
+	Description string `json:"description,omitempty"`
+}
+
+// Metadata returns the optional metadata of the version, or nil if the input provides none.
+func (v VersionInfo) Metadata() *Metadata {
+	metadata := Metadata{Author: v.Author, License: v.License, Repository: v.Repository, Description: v.Description}
+	if metadata == (Metadata{}) {
+		return nil
+	}
+	return &metadata
 }
 
 type PackageInfo struct {
@@ -35,17 +45,37 @@ type Doc struct {
 	Pkgs []PackageInfo `json:"pkgs"`
 }
 
-// NodeInfo is a type structure for nodes. Name and Version can be removed if we find we don't use them often enough
+// PackageSource is a stream of packages that a graph can be created from. The readers in the ingest package
+// implement it.
+type PackageSource interface {
+	// Next returns the next package of the stream, or io.EOF once every package has been returned.
+	Next() (PackageInfo, error)
+}
+
+// NodeInfo is a type structure for nodes. Name and Version can be removed if we find we don't use them often enough.
+// Timestamp is the zero time if the timestamp of the version is missing or could not be parsed.
 type NodeInfo struct {
-	Timestamp string
+	Timestamp time.Time
+	Ecosystem string
 	Name      string
 	Version   string
+	Metadata  *Metadata // Nil if the input has no metadata for the version
 	id        int64
 }
 
+// Metadata holds the descriptive fields of a package version that some inputs provide. Every field is optional.
+type Metadata struct {
+	Author      string `json:"author,omitempty"`
+	License     string `json:"license,omitempty"`
+	Repository  string `json:"repository,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
 type GraphEdge struct {
 	g        *simple.DirectedGraph // Graph pointer
 	FId, TId int64                 // From id, To id
+	Kind     string                // Kind of the dependency, empty for regular dependencies
+	Valid    Validity              // When the dependency resolved to this version
 }
 
 func (e GraphEdge) From() graph.Node {
@@ -57,69 +87,59 @@ func (e GraphEdge) To() graph.Node {
 }
 
 func (e GraphEdge) ReversedEdge() graph.Edge {
-	return GraphEdge{FId: e.TId, TId: e.FId, g: e.g}
+	return GraphEdge{FId: e.TId, TId: e.FId, Kind: e.Kind, Valid: e.Valid, g: e.g}
 }
 
-var crcTable *crc64.Table = crc64.MakeTable(crc64.ISO)
-var r *regexp.Regexp = regexp.MustCompile("((?P<open>[\\(\\[])(?P<bothVer>((?P<firstVer>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)(?P<comma1>,)(?P<secondVer1>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?)|((?P<comma2>,)?(?P<secondVer2>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?))(?P<close>[\\)\\]]))|(?P<simplevers>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)")
-
-const maxConcurrent = 2 // The max amount of goroutines the CreateEdgesConcurrent function can spawn
-
-// NewNodeInfo constructs a NodeInfo structure and automatically fills the stringID.
-func NewNodeInfo(id int64, name string, version string, timestamp string) *NodeInfo {
+// NewNodeInfo constructs a NodeInfo structure.
+func NewNodeInfo(id int64, ecosystem string, name string, version string, timestamp time.Time, metadata *Metadata) *NodeInfo {
 	return &NodeInfo{
 		id: id,
 
+		Ecosystem: ecosystem,
 		Name:      name,
 		Version:   version,
-		Timestamp: timestamp}
+		Timestamp: timestamp,
+		Metadata:  metadata}
 }
 
-func (nodeInfo NodeInfo) String() string {
-	return fmt.Sprintf("Package: %v - Version: %v", nodeInfo.Name, nodeInfo.Version)
-}
-
-// CreateStringIDToNodeInfoMap takes a list of PackageInfo and a simple.DirectedGraph. For each of the packages,
-// it creates a mapping of stringIDs to NodeInfo and also adds a node to the graph. The handling of the IDs is delegated
-// to Gonum. These IDs are also included in the mapping for ease of access.
-func CreateStringIDToNodeInfoMap(packagesInfo *[]PackageInfo, graph *simple.DirectedGraph) map[string]NodeInfo {
-	stringIDToNodeInfoMap := make(map[string]NodeInfo, len(*packagesInfo))
-	for _, packageInfo := range *packagesInfo {
-		for packageVersion, versionInfo := range packageInfo.Versions {
-			packageNameVersionString := fmt.Sprintf("%s-%s", packageInfo.Name, packageVersion)
-			// Delegate the work of creating a unique ID to Gonum
-			newNode := graph.NewNode()
-			newId := newNode.ID()
-			stringIDToNodeInfoMap[packageNameVersionString] = *NewNodeInfo(newId, packageInfo.Name, packageVersion, versionInfo.Timestamp)
-			// idToNodeInfo[newId] =
-			graph.AddNode(newNode)
-		}
+// newVersionNodeInfo creates the NodeInfo of a package version, with its timestamp parsed by the ecosystem. If the
+// timestamp cannot be parsed, the node gets the zero time and the returned issue reports it.
+func newVersionNodeInfo(id int64, key PackageVersion, versionInfo VersionInfo, ecosystem Ecosystem) (NodeInfo, *Issue) {
+	timestamp, err := ecosystem.ParseTimestamp(versionInfo.Timestamp)
+	nodeInfo := *NewNodeInfo(id, key.Ecosystem, key.Name, key.Version, timestamp, versionInfo.Metadata())
+	if err != nil {
+		return nodeInfo, &Issue{Kind: UnparsableTimestamp, Package: key.Name, Version: key.Version, Detail: err.Error()}
 	}
-	return stringIDToNodeInfoMap
+	return nodeInfo, nil
 }
 
-// TODO: Maybe change to something like CreateIdToNodeInfoMap so it's not confusing for other people.
+// PackageVersion returns the key of the package version of the node.
+func (nodeInfo NodeInfo) PackageVersion() PackageVersion {
+	return PackageVersion{Ecosystem: nodeInfo.Ecosystem, Name: nodeInfo.Name, Version: nodeInfo.Version}
+}
 
-func CreateNodeIdToPackageMap(m map[string]NodeInfo) map[int64]NodeInfo {
-	s := make(map[int64]NodeInfo, len(m))
-	for _, val := range m {
-		s[val.id] = val
-	}
-	return s
+func (nodeInfo NodeInfo) String() string {
+	return nodeInfo.PackageVersion().String()
 }
 
-func CreateHashedVersionMap(pi *[]PackageInfo) map[uint32][]string {
-	result := make(map[uint32][]string, len(*pi))
+// CreateVersionIndex creates an index of the versions of every package.
+func CreateVersionIndex(pi *[]PackageInfo) *VersionIndex {
+	result := NewVersionIndex(len(*pi))
 	for _, pkg := range *pi {
-		hashedName := hashPackageName(pkg.Name)
-		result[hashedName] = make([]string, 0, len(pkg.Versions))
-		for ver := range pkg.Versions {
-			result[hashedName] = append(result[hashedName], ver)
-		}
+		addPackageVersions(pkg, result)
 	}
 	return result
 }
 
+// addPackageVersions adds the versions of a single package to the index created by CreateVersionIndex.
+func addPackageVersions(pkg PackageInfo, versions *VersionIndex) {
+	packageVersions := make([]string, 0, len(pkg.Versions))
+	for ver := range pkg.Versions {
+		packageVersions = append(packageVersions, ver)
+	}
+	versions.Add(pkg.Name, packageVersions...)
+}
+
 func CreateNameToVersionMap(m *[]PackageInfo) map[string][]string {
 	newMap := make(map[string][]string, len(*m))
 	for _, value := range *m {
@@ -131,7 +151,7 @@ func CreateNameToVersionMap(m *[]PackageInfo) map[string][]string {
 	return newMap
 }
 
-//Function to write the simple graph to a dot file so it could be visualized with GraphViz. This includes only Ids
+// Function to write the simple graph to a dot file so it could be visualized with GraphViz. This includes only Ids
 func Visualization(graph *simple.DirectedGraph, name string) {
 	result, _ := dot.Marshal(graph, name, "", "  ")
 
@@ -146,8 +166,8 @@ func Visualization(graph *simple.DirectedGraph, name string) {
 
 }
 
-//Writes to dot file manually from the NodeInfoMap to include the Node info in the graphViz
-//TODO: Optimize in the future since this is kind of barbaric probably there is a faster way.
+// Writes to dot file manually from the NodeInfoMap to include the Node info in the graphViz
+// TODO: Optimize in the future since this is kind of barbaric probably there is a faster way.
 func VisualizationNodeInfo(iDToNodeInfo map[int64]NodeInfo, graph *simple.DirectedGraph, name string) {
 	file, err := os.Create(name + ".dot")
 	d1 := []byte("strict digraph" + " " + name + " " + "{\n")
@@ -159,7 +179,7 @@ func VisualizationNodeInfo(iDToNodeInfo map[int64]NodeInfo, graph *simple.Direct
 
 	for _, element := range iDToNodeInfo {
 		//fmt.Println("Key:", key, "=>", "Element:", element.id)
-		fmt.Fprintf(file, fmt.Sprint(element.id)+lab+element.Name+` \n `+string(element.Version)+` \n `+string(element.Timestamp)+"\""+"];\n")
+		fmt.Fprintf(file, fmt.Sprint(element.id)+lab+element.Name+` \n `+string(element.Version)+` \n `+element.Timestamp.Format(time.RFC3339)+"\""+"];\n")
 
 	}
 
@@ -177,162 +197,25 @@ func VisualizationNodeInfo(iDToNodeInfo map[int64]NodeInfo, graph *simple.Direct
 
 }
 
-// CreateEdges takes a graph, a list of packages and their dependencies, a map of stringIDs to NodeInfo and
-// a map of names to versions and creates directed edges between the dependent library and its dependencies.
-// TODO: add documentation on how we use semver for edges
-// TODO: Discuss removing pointers from maps since they are reference types without the need of using * : https://stackoverflow.com/questions/40680981/are-maps-passed-by-value-or-by-reference-in-go
-func CreateEdges(graph *simple.DirectedGraph, inputList *[]PackageInfo, hashToNodeId map[uint64]int64, nodeInfoMap map[int64]NodeInfo, hashToVersionMap map[uint32][]string, isMaven bool) {
-	// r, _ := regexp.Compile("((?P<open>[\\(\\[])(?P<bothVer>((?P<firstVer>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)(?P<comma1>,)(?P<secondVer1>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?)|((?P<comma2>,)?(?P<secondVer2>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)?))(?P<close>[\\)\\]]))|(?P<simplevers>(0|[1-9]+)(\\.(0|[1-9]+)(\\.(0|[1-9]+))?)?)")
-	packagesLength := len(*inputList)
-	edgesAmount := 0
-	channel := make(chan int, 2)
-	go func(n int, ch chan int) {
-		for {
-			for i := range ch {
-				fmt.Printf("\u001b[1A \u001b[2K \r") // Clear the last line
-				fmt.Printf("%.2f%% done (%d / %d packages connected to their dependencies)\n", float64(i)/float64(n)*100, i, n)
-			}
-		}
-	}(packagesLength, channel)
+// CreateEdges takes a graph, a list of packages and their dependencies, the index and NodeInfo of the nodes and the
+// index of the versions of every package, and creates directed edges between the dependent library and its
+// dependencies. A dependency gets an edge to every version of the dependency that satisfies its constraint, as the
+// ecosystem interprets them. Constraints are resolved through a ResolutionCache, so a constraint that many packages
+// share is only matched once. Every dependency that does not result in the edges it should is recorded in the returned
+// Diagnostics, together with the statistics of the cache. CreateEdgesConcurrent does the same with multiple goroutines.
+func CreateEdges(graph *simple.DirectedGraph, inputList *[]PackageInfo, nodes *NodeIndex, nodeInfoMap map[int64]NodeInfo, versions *VersionIndex, ecosystem Ecosystem) *Diagnostics {
+	cache := NewResolutionCache(ecosystem, nodes, nodeInfoMap, versions)
+	diagnostics := NewDiagnostics(DefaultMaxExamples)
+	progress := printProgress(len(*inputList))
 	for id, packageInfo := range *inputList {
-		for version, dependencyInfo := range packageInfo.Versions {
-			for dependencyName, dependencyVersion := range dependencyInfo.Dependencies {
-				finaldep := dependencyVersion
-				if isMaven {
-					finaldep = parseMultipleMavenSemVers(dependencyVersion, r)
-				}
-				constraint, err := semver.NewConstraint(finaldep)
-				if err != nil {
-					continue
-				}
-				for _, v := range LookupVersions(dependencyName, hashToVersionMap) {
-					newVersion, err := semver.NewVersion(v)
-					if err != nil {
-						continue
-					}
-					if constraint.Check(newVersion) {
-						dependencyStringId := fmt.Sprintf("%s-%s", dependencyName, v)
-						dependencyGoId := LookupByStringId(dependencyStringId, hashToNodeId)
-
-						packageStringId := fmt.Sprintf("%s-%s", packageInfo.Name, version)
-						packageGoId := LookupByStringId(packageStringId, hashToNodeId)
-
-						// Ensure that we do not create edges to self because some packages do that...
-						if dependencyGoId != packageGoId {
-							graph.SetEdge(GraphEdge{FId: packageGoId, TId: dependencyGoId, g: graph})
-							edgesAmount++
-						}
-
-					}
-				}
-			}
-		}
-		channel <- id
-	}
-	close(channel)
-}
-
-func addEdge(graphMutex *sync.RWMutex, dependencyName string, v string, hashToNodeId map[uint64]int64, graph *simple.DirectedGraph, packageName string, packageVersion string) {
-	graphMutex.RLock()
-	dependencyStringId := fmt.Sprintf("%s-%s", dependencyName, v)
-	dependencyGoId := LookupByStringId(dependencyStringId, hashToNodeId)
-	dependencyNode := graph.Node(dependencyGoId)
-
-	packageStringId := fmt.Sprintf("%s-%s", packageName, packageVersion)
-	packageGoId := LookupByStringId(packageStringId, hashToNodeId)
-	packageNode := graph.Node(packageGoId)
-	graphMutex.RUnlock()
-	if packageGoId != dependencyGoId { // This prevents self-loops
-		graphMutex.Lock()
-		graph.SetEdge(simple.Edge{F: packageNode, T: dependencyNode})
-		graphMutex.Unlock() // We're done, release it to the next goroutine
-	}
-}
-
-func parseMultipleMavenSemVers(s string, reg *regexp.Regexp) string {
-	var finalResult string
-	chars := []rune(s)
-	openIndex := 0
-	closeIndex := 0
-	for i := 0; i < len(chars); i++ {
-		char := string(chars[i])
-		if char == "(" || char == "[" {
-			openIndex = i
-		}
-		if char == ")" || char == "]" {
-			closeIndex = i
-			if i != len(chars)-1 {
-				finalResult += translateMavenSemver(s[openIndex:closeIndex+1], reg) + " || "
-			} else {
-				finalResult += translateMavenSemver(s[openIndex:closeIndex+1], reg)
-			}
-		}
-
-	}
-	if closeIndex == 0 && openIndex == 0 {
-		return translateMavenSemver(s, reg)
-	}
-
-	return finalResult
-}
-
-func translateMavenSemver(s string, reg *regexp.Regexp) string {
-	match := reg.FindStringSubmatch(s)
-	if match == nil {
-		return s
-	}
-	result := make(map[string]string)
-	var finalResult string
-	if s == "unspecified" || s == "LATEST" {
-		return ">= 0.0.0"
-	}
-	for i, name := range reg.SubexpNames() {
-		if i != 0 && name != "" {
-			result[name] = match[i]
-		}
-		//TODO: What is happening here?
-		//fmt.Printf("by name: %s %s\n", result["singur"])
-	}
-	if len(result["close"]) > 0 {
-		if len(result["secondVer2"]) > 0 {
-			if len(result["comma1"]) > 0 || len(result["comma2"]) > 0 {
-				switch result["close"] {
-				case "]":
-					finalResult = "<= " + result["secondVer2"]
-				case ")":
-					finalResult = "< " + result["secondVer2"]
-				}
-			} else {
-				finalResult = "= " + result["secondVer2"]
-			}
-		} else {
-			if len(result["firstVer"]) > 0 && len(result["secondVer1"]) > 0 {
-				switch result["open"] {
-				case "[":
-					finalResult = ">= " + result["firstVer"] + ", "
-				case "(":
-					finalResult = "> " + result["firstVer"] + ", "
-				}
-				switch result["close"] {
-				case "]":
-					finalResult += "<= " + result["secondVer1"]
-				case ")":
-					finalResult += "< " + result["secondVer1"]
-				}
-			} else if len(result["firstVer"]) > 0 && len(result["secondVer1"]) == 0 {
-				switch result["open"] {
-				case "[":
-					finalResult = ">= " + result["firstVer"]
-				case "(":
-					finalResult = "> " + result["firstVer"]
-				}
-			}
-		}
-	} else {
-		finalResult = ">= " + result["simplevers"]
+		batch := resolveEdges([]PackageInfo{packageInfo}, nodes, cache)
+		batch.apply(graph, diagnostics)
+		progress <- id
 	}
-	return finalResult
-
+	close(progress)
+	diagnostics.Ecosystem = ecosystem.Name()
+	diagnostics.Cache = cache.Stats()
+	return diagnostics
 }
 
 func ParseJSON(inPath string) []PackageInfo {
@@ -348,74 +231,125 @@ func ParseJSON(inPath string) []PackageInfo {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("Read %d packages\n", len(result.Pkgs))
+	fmt.Fprintf(Progress, "Read %d packages\n", len(result.Pkgs))
 
 	return result.Pkgs
 }
 
-func CreateMaps(packageList *[]PackageInfo, graph *simple.DirectedGraph) (map[uint64]int64, map[int64]NodeInfo) {
-	hashToNodeId := make(map[uint64]int64, len(*packageList)*10)
+// CreateMaps adds a node for every version of every package to the graph, and returns the index to find the nodes
+// and the NodeInfo of every node. It panics if a package version is in the list twice with different timestamps.
+func CreateMaps(packageList *[]PackageInfo, graph *simple.DirectedGraph, ecosystem Ecosystem) (*NodeIndex, map[int64]NodeInfo) {
+	nodes := NewNodeIndex(len(*packageList) * 10)
 	idToNodeInfo := make(map[int64]NodeInfo, len(*packageList)*10)
 	for _, packageInfo := range *packageList {
-		for packageVersion, versionInfo := range packageInfo.Versions {
-			stringID := fmt.Sprintf("%s-%s", packageInfo.Name, packageVersion)
-			hashed := hashStringId(stringID)
-			// Delegate the work of creating a unique ID to Gonum
-			newNode := graph.NewNode()
-			newId := newNode.ID()
-			hashToNodeId[hashed] = newId
-			idToNodeInfo[newId] = *NewNodeInfo(newId, packageInfo.Name, packageVersion, versionInfo.Timestamp)
-			graph.AddNode(newNode)
+		if _, _, err := addPackageNodes(packageInfo, ecosystem, graph, nodes, idToNodeInfo); err != nil {
+			panic(err)
 		}
 	}
-	return hashToNodeId, idToNodeInfo
-}
-
-func hashStringId(stringID string) uint64 {
-	hashed := crc64.Checksum([]byte(stringID), crcTable)
-	return hashed
-}
-
-func hashPackageName(packageName string) uint32 {
-	hashed := crc32.ChecksumIEEE([]byte(packageName))
-	return hashed
+	return nodes, idToNodeInfo
+}
+
+// addPackageNodes adds a node for every version of a single package to the graph and to the maps created by
+// CreateMaps, and returns the versions that got a node and the timestamps that could not be parsed. Versions that
+// already have a node, because the package was read before, are skipped, unless they were read with another timestamp:
+// the package version would then have two nodes, so an error is returned instead.
+func addPackageNodes(packageInfo PackageInfo, ecosystem Ecosystem, graph *simple.DirectedGraph, nodes *NodeIndex, idToNodeInfo map[int64]NodeInfo) ([]string, []Issue, error) {
+	added := make([]string, 0, len(packageInfo.Versions))
+	var issues []Issue
+	for packageVersion, versionInfo := range packageInfo.Versions {
+		key := PackageVersion{Ecosystem: ecosystem.Name(), Name: packageInfo.Name, Version: packageVersion}
+		if id, ok := nodes.Lookup(key); ok {
+			existing := idToNodeInfo[id]
+			if duplicate, _ := newVersionNodeInfo(id, key, versionInfo, ecosystem); !duplicate.Timestamp.Equal(existing.Timestamp) {
+				return nil, nil, fmt.Errorf("package version %s is read twice, with timestamps %s and %s", key,
+					existing.Timestamp, duplicate.Timestamp)
+			}
+			continue
+		}
+		// Delegate the work of creating a unique ID to Gonum
+		newNode := graph.NewNode()
+		newId := newNode.ID()
+		if err := nodes.Add(key, newId); err != nil {
+			return nil, nil, err
+		}
+		nodeInfo, issue := newVersionNodeInfo(newId, key, versionInfo, ecosystem)
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+		idToNodeInfo[newId] = nodeInfo
+		graph.AddNode(newNode)
+		added = append(added, packageVersion)
+	}
+	return added, issues, nil
 }
 
-func LookupVersions(packageName string, versionMap map[uint32][]string) []string {
-	hash := hashPackageName(packageName)
-	return versionMap[hash]
-}
+// Progress is where CreateGraph and the other functions that take a while report their progress. Set it to os.Stderr
+// to keep standard output free for results, or to io.Discard to silence them.
+var Progress io.Writer = os.Stdout
 
-func LookupByStringId(stringId string, hashTable map[uint64]int64) int64 {
-	hash := hashStringId(stringId)
-	goId := hashTable[hash]
-	return goId
+// CreateGraph reads all packages from the source and creates the dependency graph from them. Any reader registered
+// in the ingest package can be used as the source. Nodes are created while the packages are being read, so of every
+// package only the dependencies of its versions, which are needed to create the edges afterwards, are kept in memory.
+// The edges are created by the given amount of workers; with a single worker they are created sequentially. A package
+// that is read more than once gets a node for each of its versions only once, and an error is returned if one of its
+// versions is read again with another timestamp.
+func CreateGraph(source PackageSource, ecosystem Ecosystem, workers int) (*simple.DirectedGraph, *NodeIndex, map[int64]NodeInfo, *VersionIndex, *Diagnostics, error) {
+	fmt.Fprintln(Progress, "Parsing input, adding nodes and creating indices")
+	graph := simple.NewDirectedGraph()
+	nodes := NewNodeIndex(0)
+	idToNodeInfo := make(map[int64]NodeInfo)
+	versions := NewVersionIndex(0)
+	var packagesList []PackageInfo // The dependencies of the packages, which are needed to create the edges
+	var timestampIssues []Issue
+	read := 0
+	for {
+		packageInfo, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		packageInfo.Name = ecosystem.NormalizeName(packageInfo.Name)
+		added, issues, err := addPackageNodes(packageInfo, ecosystem, graph, nodes, idToNodeInfo)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+		timestampIssues = append(timestampIssues, issues...)
+		versions.Add(packageInfo.Name, added...)
+		if dependencies := dependenciesOnly(packageInfo); len(dependencies.Versions) > 0 {
+			packagesList = append(packagesList, dependencies)
+		}
+		read++
+	}
+	fmt.Fprintf(Progress, "Read %d packages\n", read)
+	fmt.Fprintln(Progress, "Creating edges")
+	fmt.Fprintln(Progress)
+	var diagnostics *Diagnostics
+	if workers > 1 {
+		diagnostics = CreateEdgesConcurrent(graph, &packagesList, nodes, idToNodeInfo, versions, ecosystem, workers)
+	} else {
+		diagnostics = CreateEdges(graph, &packagesList, nodes, idToNodeInfo, versions, ecosystem)
+	}
+	for _, issue := range timestampIssues {
+		diagnostics.record(issue)
+	}
+	fmt.Fprintln(Progress, "Done!")
+	fmt.Fprintf(Progress, "Nodes: %d, Edges: %d\n", nodes.Len(), diagnostics.Edges)
+	return graph, nodes, idToNodeInfo, versions, diagnostics, nil
 }
 
-func CreateGraph(inputPath string, isUsingMaven bool) (*simple.DirectedGraph, map[uint64]int64, map[int64]NodeInfo, map[uint32][]string) {
-	fmt.Println("Parsing input")
-	packagesList := ParseJSON(inputPath)
-	// runtime.GC()
-	graph := simple.NewDirectedGraph()
-	// stringIDToNodeInfo := CreateStringIDToNodeInfoMap(packagesList, graph)
-	// idToNodeInfo := CreateNodeIdToPackageMap(stringIDToNodeInfo)
-	fmt.Println("Adding nodes and creating indices")
-	hashToNodeId, idToNodeInfo := CreateMaps(&packagesList, graph)
-	// nameToVersions := CreateNameToVersionMap(&packagesList)
-	hashToVersions := CreateHashedVersionMap(&packagesList)
-	fmt.Println("Creating edges")
-	fmt.Println()
-	CreateEdges(graph, &packagesList, hashToNodeId, idToNodeInfo, hashToVersions, isUsingMaven)
-	//CreateEdgesConcurrent(graph, &packagesList, hashToNodeId, idToNodeInfo, nameToVersions, isUsingMaven)
-	fmt.Println("Done!")
-	// TODO: This might cause some issues but for now it saves it quite a lot of memory
-	runtime.GC()
-	numNodes := graph.Nodes().Len()
-	runtime.GC()
-	numEdges := graph.Edges().Len()
-	runtime.GC()
-	fmt.Printf("Nodes: %d, Edges: %d\n", numNodes, numEdges)
-	return graph, hashToNodeId, idToNodeInfo, hashToVersions
+// dependenciesOnly returns the package with only what creating its edges needs: the dependencies of its versions and
+// their kinds. The timestamps and metadata are in the NodeInfo of the nodes by then, and versions without dependencies
+// are left out.
+func dependenciesOnly(packageInfo PackageInfo) PackageInfo {
+	result := PackageInfo{Name: packageInfo.Name, Versions: make(map[string]VersionInfo)}
+	for version, versionInfo := range packageInfo.Versions {
+		if len(versionInfo.Dependencies) > 0 {
+			result.Versions[version] = VersionInfo{Dependencies: versionInfo.Dependencies, Kinds: versionInfo.Kinds}
+		}
+	}
+	return result
 }
 
 // This function returns true when time t lies in the interval [begin, end], false otherwise
@@ -429,58 +363,64 @@ func initializeTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, wi
 	for nodes.Next() { // Initialize withinInterval data structure
 		n := nodes.Node()
 		id := n.ID()
-		publishTime, err := time.Parse(time.RFC3339, nodeMap[id].Timestamp)
-		if err != nil {
-			panic(err)
-		}
-		if InInterval(publishTime, beginTime, endTime) {
+		if InInterval(nodeMap[id].Timestamp, beginTime, endTime) {
 			withinInterval[id] = true
 		}
 	}
 
 }
 
-func removeDisconnected(g *simple.DirectedGraph, connected []*graph.Edge) {
+// edgeKey identifies the edge between two nodes by their IDs.
+type edgeKey struct {
+	from, to int64
+}
+
+// timeTraversal returns a breadth-first traversal that only follows edges to dependencies that were published within
+// the interval, and before their dependent. Every edge it follows is added to connected.
+func timeTraversal(nodeMap map[int64]NodeInfo, withinInterval map[int64]bool, connected map[edgeKey]struct{}) *traverse.BreadthFirst {
+	return &traverse.BreadthFirst{
+		Traverse: func(e graph.Edge) bool { // The dependent / parent node
+			fromId := e.From().ID()
+			toId := e.To().ID()
+			if !withinInterval[toId] {
+				return false
+			}
+			fromTime := nodeMap[fromId].Timestamp // The dependent node's time stamp
+			toTime := nodeMap[toId].Timestamp     // The dependency node's time stamp
+			// If the dependency was released before the parent node, add this edge to the connected edges
+			if !fromTime.After(toTime) {
+				return false
+			}
+			connected[edgeKey{fromId, toId}] = struct{}{}
+			return true
+		},
+	}
+}
+
+// pruneEdges removes every edge from g that is not in connected. The edges are collected before any is removed, so each
+// one is looked up in connected once.
+func pruneEdges(g *simple.DirectedGraph, connected map[edgeKey]struct{}) {
+	var disconnected []edgeKey
 	edges := g.Edges()
 	for edges.Next() {
 		edge := edges.Edge()
-		for _, disconnectedEdge := range connected { // Found that it's connected, move on
-			if edge == *disconnectedEdge {
-				break
-			} else {
-				g.RemoveEdge(edge.From().ID(), edge.To().ID())
-			}
+		key := edgeKey{edge.From().ID(), edge.To().ID()}
+		if _, ok := connected[key]; !ok {
+			disconnected = append(disconnected, key)
 		}
 	}
+	for _, key := range disconnected {
+		g.RemoveEdge(key.from, key.to)
+	}
 }
 
-// This function removes stale edges from the specified graph by doing a DFS with all packages as the root node in O(n^2)
+// This function removes stale edges from the specified graph by doing a BFS with every package within the interval as
+// the root node. Only the edges that one of these traversals followed are kept.
 func traverseAndRemoveEdges(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, withinInterval map[int64]bool) {
 	nodes := g.Nodes()
 	// This keeps track of which edges we've connected
-	connected := make([]*graph.Edge, 0, len(nodeMap)*2)
-
-	t := traverse.BreadthFirst{
-		Traverse: func(e graph.Edge) bool { // The dependent / parent node
-			var traversal bool
-			fromId := e.From().ID()
-			toId := e.To().ID()
-			if withinInterval[toId] {
-				fromTime, err1 := time.Parse(time.RFC3339, nodeMap[fromId].Timestamp) // The dependent node's time stamp
-				toTime, err2 := time.Parse(time.RFC3339, nodeMap[toId].Timestamp)     // The dependency node's time stamp
-
-				if err1 != nil || err2 != nil {
-					panic(err1)
-				}
-
-				if traversal = fromTime.After(toTime); traversal {
-					connected = append(connected, &e)
-				} // If the dependency was released before the parent node, add this edge to the connected nodes
-			}
-
-			return traversal
-		},
-	}
+	connected := make(map[edgeKey]struct{}, len(nodeMap))
+	t := timeTraversal(nodeMap, withinInterval, connected)
 	for nodes.Next() {
 		n := nodes.Node()
 		if withinInterval[n.ID()] { // We'll only consider traversing this subtree if its root was within the specified time interval
@@ -489,37 +429,15 @@ func traverseAndRemoveEdges(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo,
 		}
 	}
 
-	removeDisconnected(g, connected)
-
+	pruneEdges(g, connected)
 }
 
+// traverseOneNode removes every edge from g that a traversal from the node within the interval does not follow, so
+// only the edges of its dependency tree in the interval are left, like traverseAndRemoveEdges does for every node.
 func traverseOneNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, withinInterval map[int64]bool, nodeId int64) {
-	connected := make([]*graph.Edge, 0, len(nodeMap)*2)
-
-	t := traverse.BreadthFirst{
-		Traverse: func(e graph.Edge) bool { // The dependent / parent node
-			var traversal bool
-			fromId := e.From().ID()
-			toId := e.To().ID()
-			if withinInterval[toId] {
-				fromTime, err1 := time.Parse(time.RFC3339, nodeMap[fromId].Timestamp) // The dependent node's time stamp
-				toTime, err2 := time.Parse(time.RFC3339, nodeMap[toId].Timestamp)     // The dependency node's time stamp
-
-				if err1 != nil || err2 != nil {
-					panic(err1)
-				}
-
-				if traversal = fromTime.After(toTime); traversal {
-					connected = append(connected, &e)
-				} // If the dependency was released before the parent node, add this edge to the connected nodes
-			}
-
-			return traversal
-		},
-	}
-
-	_ = t.Walk(g, g.Node(nodeId), nil)
-	removeDisconnected(g, connected)
+	connected := make(map[edgeKey]struct{})
+	_ = timeTraversal(nodeMap, withinInterval, connected).Walk(g, g.Node(nodeId), nil)
+	pruneEdges(g, connected)
 }
 
 func filterGraph(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, beginTime, endTime time.Time) {
@@ -534,26 +452,23 @@ func FilterGraph(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, beginTime,
 	FilterNoTraversal(g, nodeMap, beginTime, endTime)
 }
 
-func findNode(hashMap map[uint64]int64, idToNodeInfo map[int64]NodeInfo, stringId string) (int64, bool) {
-	var nodeId int64
-	var correctOk bool
-	if info, ok := idToNodeInfo[LookupByStringId(stringId, hashMap)]; ok {
-		nodeId = info.id
-		correctOk = true
-	} else {
-		log.Printf("String id %s was not found \n", stringId)
-		correctOk = false
+func findNode(nodes *NodeIndex, key PackageVersion) (int64, bool) {
+	nodeId, ok := nodes.Lookup(key)
+	if !ok {
+		log.Printf("Package version %s was not found \n", key)
 	}
-	return nodeId, correctOk
+	return nodeId, ok
 }
 
-func FilterNode(g *simple.DirectedGraph, hashMap map[uint64]int64, nodeMap map[int64]NodeInfo, stringId string, beginTime, endTime time.Time) {
+// FilterNode removes every edge from g except the edges of the dependency tree of the package version, which
+// ParsePackageVersion can read from a package URL, that lie within the interval.
+func FilterNode(g *simple.DirectedGraph, nodes *NodeIndex, nodeMap map[int64]NodeInfo, key PackageVersion, beginTime, endTime time.Time) {
 
 	var nodeId int64
-	if id, ok := findNode(hashMap, nodeMap, stringId); ok {
+	if id, ok := findNode(nodes, key); ok {
 		nodeId = id
 	} else {
-		return // This function is a no-op if we don't have a correct string id
+		return // This function is a no-op if we don't have a correct package version
 	}
 
 	// This stores whether the package existed in the specified time range
@@ -564,14 +479,15 @@ func FilterNode(g *simple.DirectedGraph, hashMap map[uint64]int64, nodeMap map[i
 	traverseOneNode(g, nodeMap, withinInterval, nodeId)
 }
 
-// This function returns the specified node and its dependencies
-func GetTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, hashMap map[uint64]int64, stringId string) *[]NodeInfo {
+// This function returns the specified node and its dependencies. Package URLs, such as the ones in SBOMs, can be
+// turned into the package version with ParsePackageVersion.
+func GetTransitiveDependenciesNode(g graph.Directed, nodeMap map[int64]NodeInfo, nodes *NodeIndex, key PackageVersion) *[]NodeInfo {
 	var nodeId int64
 	result := make([]NodeInfo, 0, len(nodeMap)/2)
-	if id, ok := findNode(hashMap, nodeMap, stringId); ok {
+	if id, ok := findNode(nodes, key); ok && g.Node(id) != nil {
 		nodeId = id
 	} else {
-		return &result // This function is a no-op if we don't have a correct string id
+		return &result // This function is a no-op if we don't have a correct package version, or it is not in the view
 	}
 
 	w := traverse.DepthFirst{
@@ -584,10 +500,11 @@ func GetTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[int64]No
 	return &result
 }
 
-// Get the latest dependencies matching the node's version constraints. If you want this within a specific time frame, use filterNode first
-func GetLatestTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, hashMap map[uint64]int64, stringId string) *[]NodeInfo {
+// Get the latest dependencies matching the node's version constraints. If you want this within a specific time frame,
+// run it on a ValidView of a WindowView of the time frame
+func GetLatestTransitiveDependenciesNode(g graph.Directed, nodeMap map[int64]NodeInfo, nodes *NodeIndex, ecosystem Ecosystem, key PackageVersion) *[]NodeInfo {
 	var rootNode NodeInfo
-	allDeps := GetTransitiveDependenciesNode(g, nodeMap, hashMap, stringId)
+	allDeps := GetTransitiveDependenciesNode(g, nodeMap, nodes, key)
 	result := make([]NodeInfo, 0, len(*allDeps)/2)
 	if len(*allDeps) > 1 {
 		rootNode = (*allDeps)[0]
@@ -595,7 +512,7 @@ func GetLatestTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[in
 		return &result // No-op if no dependencies were found for whatever reason
 	}
 
-	newestPackageVersion := make(map[uint32]NodeInfo, len(*allDeps)/2)
+	newestPackageVersion := make(map[string]NodeInfo, len(*allDeps)/2)
 
 	result = append(result, rootNode)
 
@@ -606,28 +523,21 @@ func GetLatestTransitiveDependenciesNode(g *simple.DirectedGraph, nodeMap map[in
 			continue
 		}
 
-		hash := hashPackageName(current.Name)
-		currentDate, err := time.Parse(time.RFC3339, current.Timestamp)
-		if err != nil {
+		currentDate := current.Timestamp
+		if currentDate.IsZero() { // Versions without a timestamp cannot be compared
 			continue
 		}
-		if latest, ok := newestPackageVersion[hash]; ok {
-			latestDate, err := time.Parse(time.RFC3339, latest.Timestamp)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			} else if currentDate.After(latestDate) { // If the key exists, and current date is later than the one stored
-				newestPackageVersion[hash] = current // Set to the current package
+		if latest, ok := newestPackageVersion[current.Name]; ok {
+			latestDate := latest.Timestamp
+			if currentDate.After(latestDate) { // If the key exists, and current date is later than the one stored
+				newestPackageVersion[current.Name] = current // Set to the current package
 			} else if currentDate.Equal(latestDate) { // If the dates are somehow equal, compare version numbers
-				currentversion, _ := semver.NewVersion(current.Version)
-				latestVersion, _ := semver.NewVersion(latest.Version)
-
-				if currentversion.GreaterThan(latestVersion) {
-					newestPackageVersion[hash] = current
+				if compareVersions(ecosystem, current.Version, latest.Version) > 0 {
+					newestPackageVersion[current.Name] = current
 				}
 			}
 		} else { // If the key doesn't exist yet
-			newestPackageVersion[hash] = current
+			newestPackageVersion[current.Name] = current
 		}
 	}
 
@@ -658,37 +568,41 @@ func keepSelectedNodes(g *simple.DirectedGraph, removeIDs map[int64]struct{}) {
 	}
 }
 
-func LatestNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo) {
-	length := g.Nodes().Len() / 2
-	newestPackageVersion := make(map[uint32]NodeInfo, length)
-	keepIDs := make(map[int64]struct{}, length)
-	removeIDs := make(map[int64]struct{}, length)
-	nodes := g.Nodes()
-
+// latestVersions returns the IDs of the nodes that are the latest version of their package among the nodes.
+func latestVersions(nodes graph.Nodes, nodeMap map[int64]NodeInfo, ecosystem Ecosystem) map[int64]struct{} {
+	length := nodes.Len() / 2
+	newestPackageVersion := make(map[string]NodeInfo, length)
 	for nodes.Next() {
 		n := nodes.Node()
 		current := nodeMap[n.ID()]
-		currentDate, _ := time.Parse(time.RFC3339, current.Timestamp)
-		hash := hashPackageName(current.Name)
+		currentDate := current.Timestamp
 
-		if latest, ok := newestPackageVersion[hash]; ok {
-			latestDate, _ := time.Parse(time.RFC3339, latest.Timestamp)
+		if latest, ok := newestPackageVersion[current.Name]; ok {
+			latestDate := latest.Timestamp
 			if currentDate.After(latestDate) { // If the key exists, and current date is later than the one stored
-				newestPackageVersion[hash] = current // Set to the current package
+				newestPackageVersion[current.Name] = current // Set to the current package
 			} else if currentDate.Equal(latestDate) { // If the dates are somehow equal, compare version numbers
-				if strings.Compare(current.Version, latest.Version) > 1 {
-					newestPackageVersion[hash] = current
+				if compareVersions(ecosystem, current.Version, latest.Version) > 0 {
+					newestPackageVersion[current.Name] = current
 				}
 			}
 		} else { // If the key doesn't exist yet
-			newestPackageVersion[hash] = current
+			newestPackageVersion[current.Name] = current
 		}
-
 	}
 
+	keepIDs := make(map[int64]struct{}, len(newestPackageVersion))
 	for _, v := range newestPackageVersion {
 		keepIDs[v.id] = struct{}{}
 	}
+	return keepIDs
+}
+
+// LatestNoTraversal removes every node from g that is not the latest version of its package. LatestView selects the
+// same nodes without changing g.
+func LatestNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, ecosystem Ecosystem) {
+	keepIDs := latestVersions(g.Nodes(), nodeMap, ecosystem)
+	removeIDs := make(map[int64]struct{}, len(nodeMap)-len(keepIDs))
 
 	for id := range nodeMap {
 		if _, ok := keepIDs[id]; !ok { // If the node id was not on the list, kick it out
@@ -700,6 +614,8 @@ func LatestNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo) {
 
 }
 
+// FilterNoTraversal removes every node from g that was not published within the interval. WindowView selects the same
+// nodes without changing g.
 func FilterNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, beginTime, endTime time.Time) {
 	nodes := g.Nodes()
 
@@ -709,11 +625,7 @@ func FilterNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, begi
 	for nodes.Next() { // Find nodes that are in the correct time interval
 		n := nodes.Node()
 		id := n.ID()
-		publishTime, err := time.Parse(time.RFC3339, nodeMap[id].Timestamp)
-		if err != nil {
-			panic(err)
-		}
-		if InInterval(publishTime, beginTime, endTime) {
+		if InInterval(nodeMap[id].Timestamp, beginTime, endTime) {
 			nodesInInterval[id] = struct{}{}
 		}
 	}
@@ -727,71 +639,13 @@ func FilterNoTraversal(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, begi
 	keepSelectedNodes(g, removeIDs)
 }
 
-// Filter the graph between the two given time stamps and then only keep the latest dependencies
-func FilterLatestDepsGraph(g *simple.DirectedGraph, nodeMap map[int64]NodeInfo, hashMap map[uint64]int64, beginTime, endTime time.Time) {
-	filterGraph(g, nodeMap, beginTime, endTime)
-	length := g.Nodes().Len() / 2
-
-	keepIDs := make(map[int64]struct{}, length)
-	removeIDs := make(map[int64]struct{}, length)
-	newestPackageVersion := make(map[uint32]NodeInfo, length)
-	v := traverse.DepthFirst{
-		Visit: func(n graph.Node) {
-			current := nodeMap[n.ID()]
-			currentDate, _ := time.Parse(time.RFC3339, current.Timestamp)
-			hash := hashPackageName(current.Name)
-
-			if latest, ok := newestPackageVersion[hash]; ok {
-				latestDate, _ := time.Parse(time.RFC3339, latest.Timestamp)
-				if currentDate.After(latestDate) { // If the key exists, and current date is later than the one stored
-					newestPackageVersion[hash] = current // Set to the current package
-				} else if currentDate.Equal(latestDate) { // If the dates are somehow equal, compare version numbers
-					//currentversion, _ := semver.NewVersion(current.Version)
-					//latestVersion, _ := semver.NewVersion(latest.Version)
-
-					if strings.Compare(current.Version, latest.Version) > 1 {
-						newestPackageVersion[hash] = current
-					}
-				}
-			} else { // If the key doesn't exist yet
-				newestPackageVersion[hash] = current
-			}
-		},
-	}
-	nodesAmount := len(hashMap)
-	nodes := g.Nodes()
-
-	i := 0
-	for nodes.Next() {
-		n := nodes.Node()
-		_ = v.Walk(g, n, nil)
-		v.Reset()
-		i++
-		fmt.Printf("\u001b[1A \u001b[2K \r") // Clear the last line
-		fmt.Printf("%d / %d subtrees walked \n", i, nodesAmount)
-	}
-
-	for _, v := range newestPackageVersion {
-		keepIDs[v.id] = struct{}{}
-	}
-
-	for id := range nodeMap {
-		if _, ok := keepIDs[id]; !ok { // If the node id was not on the list, kick it out
-			removeIDs[id] = struct{}{}
-		}
-	}
-
-	keepSelectedNodes(g, removeIDs)
-
-}
-
-// This uses the sparse page rank algorithm to find the Page ranks of all nodes
-func PageRank(graph *simple.DirectedGraph) map[int64]float64 {
+// This uses the sparse page rank algorithm to find the Page ranks of all nodes. Run it on a CSRGraph to save memory
+func PageRank(graph graph.Directed) map[int64]float64 {
 	pr := network.PageRankSparse(graph, 0.85, 0.01)
 	return pr
 }
 
-func Betweenness(graph *simple.DirectedGraph) map[int64]float64 {
+func Betweenness(graph graph.Directed) map[int64]float64 {
 	betweenness := network.Betweenness(graph)
 	return betweenness
 }
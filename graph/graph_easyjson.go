@@ -54,6 +54,30 @@ func easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph(in *jlexer
 				}
 				in.Delim('}')
 			}
+		case "kinds":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				out.Kinds = make(map[string]string)
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v3 string
+					v3 = string(in.String())
+					(out.Kinds)[key] = v3
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		case "author":
+			out.Author = string(in.String())
+		case "license":
+			out.License = string(in.String())
+		case "repository":
+			out.Repository = string(in.String())
+		case "description":
+			out.Description = string(in.String())
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -98,6 +122,45 @@ func easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph(out *jwrit
 			out.RawByte('}')
 		}
 	}
+	if len(in.Kinds) != 0 {
+		const prefix string = ",\"kinds\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v4First := true
+			for v4Name, v4Value := range in.Kinds {
+				if v4First {
+					v4First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v4Name))
+				out.RawByte(':')
+				out.String(string(v4Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	if in.Author != "" {
+		const prefix string = ",\"author\":"
+		out.RawString(prefix)
+		out.String(string(in.Author))
+	}
+	if in.License != "" {
+		const prefix string = ",\"license\":"
+		out.RawString(prefix)
+		out.String(string(in.License))
+	}
+	if in.Repository != "" {
+		const prefix string = ",\"repository\":"
+		out.RawString(prefix)
+		out.String(string(in.Repository))
+	}
+	if in.Description != "" {
+		const prefix string = ",\"description\":"
+		out.RawString(prefix)
+		out.String(string(in.Description))
+	}
 	out.RawByte('}')
 }
 
@@ -250,12 +313,26 @@ func easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph2(in *jlexe
 			continue
 		}
 		switch key {
+		case "Timestamp":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Timestamp).UnmarshalJSON(data))
+			}
+		case "Ecosystem":
+			out.Ecosystem = string(in.String())
 		case "Name":
 			out.Name = string(in.String())
 		case "Version":
 			out.Version = string(in.String())
-		case "Timestamp":
-			out.Timestamp = string(in.String())
+		case "Metadata":
+			if in.IsNull() {
+				in.Skip()
+				out.Metadata = nil
+			} else {
+				if out.Metadata == nil {
+					out.Metadata = new(Metadata)
+				}
+				(*out.Metadata).UnmarshalEasyJSON(in)
+			}
 		default:
 			in.AddError(&jlexer.LexerError{
 				Offset: in.GetPos(),
@@ -275,8 +352,18 @@ func easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph2(out *jwri
 	first := true
 	_ = first
 	{
-		const prefix string = ",\"Name\":"
+		const prefix string = ",\"Timestamp\":"
 		out.RawString(prefix[1:])
+		out.Raw((in.Timestamp).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"Ecosystem\":"
+		out.RawString(prefix)
+		out.String(string(in.Ecosystem))
+	}
+	{
+		const prefix string = ",\"Name\":"
+		out.RawString(prefix)
 		out.String(string(in.Name))
 	}
 	{
@@ -285,9 +372,13 @@ func easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph2(out *jwri
 		out.String(string(in.Version))
 	}
 	{
-		const prefix string = ",\"Timestamp\":"
+		const prefix string = ",\"Metadata\":"
 		out.RawString(prefix)
-		out.String(string(in.Timestamp))
+		if in.Metadata == nil {
+			out.RawString("null")
+		} else {
+			(*in.Metadata).MarshalEasyJSON(out)
+		}
 	}
 	out.RawByte('}')
 }
@@ -417,3 +508,110 @@ func (v *Doc) UnmarshalJSON(data []byte) error {
 func (v *Doc) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph3(l, v)
 }
+func easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph4(in *jlexer.Lexer, out *Metadata) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "author":
+			out.Author = string(in.String())
+		case "license":
+			out.License = string(in.String())
+		case "repository":
+			out.Repository = string(in.String())
+		case "description":
+			out.Description = string(in.String())
+		default:
+			in.AddError(&jlexer.LexerError{
+				Offset: in.GetPos(),
+				Reason: "unknown field",
+				Data:   key,
+			})
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph4(out *jwriter.Writer, in Metadata) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Author != "" {
+		const prefix string = ",\"author\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Author))
+	}
+	if in.License != "" {
+		const prefix string = ",\"license\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.License))
+	}
+	if in.Repository != "" {
+		const prefix string = ",\"repository\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Repository))
+	}
+	if in.Description != "" {
+		const prefix string = ",\"description\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Description))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Metadata) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Metadata) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2419208eEncodeGithubComAJMBrandsSoftwareThatMattersGraph4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Metadata) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Metadata) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2419208eDecodeGithubComAJMBrandsSoftwareThatMattersGraph4(l, v)
+}
@@ -2,7 +2,9 @@ package graph
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"gonum.org/v1/gonum/graph/simple"
 )
@@ -33,9 +35,9 @@ func TestNodeCreationBasicGraph(t *testing.T) {
 	}
 	//dummyMap := make(map[int64]NodeInfo)
 	graph := simple.NewDirectedGraph()
-	hashMap, nodeMap := CreateMaps(&simplePackageInfo, graph)
-	hashToVersionMap := CreateHashedVersionMap(&simplePackageInfo)
-	CreateEdges(graph, &simplePackageInfo, hashMap, nodeMap, hashToVersionMap, false)
+	nodeIndex, nodeMap := CreateMaps(&simplePackageInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&simplePackageInfo)
+	CreateEdges(graph, &simplePackageInfo, nodeIndex, nodeMap, versionIndex, NPM)
 
 	t.Run("Create two nodes because we specified two packages", func(t *testing.T) {
 
@@ -48,13 +50,13 @@ func TestNodeCreationBasicGraph(t *testing.T) {
 
 	t.Run("Create the two unique, correct nodes", func(t *testing.T) {
 		var idA, idB int64
-		if a, check := nodeMap[LookupByStringId("A-1.0.0", hashMap)]; check && graph.Node(idA) != nil {
+		if a, check := nodeMap[nodeId(t, nodeIndex, "pkg:npm/A@1.0.0")]; check && graph.Node(idA) != nil {
 			idA = a.id
 		} else {
 			t.Error("Node A-1.0.0 didn't exist")
 		}
 
-		if b, check := nodeMap[LookupByStringId("B-1.0.0", hashMap)]; check && graph.Node(idB) != nil {
+		if b, check := nodeMap[nodeId(t, nodeIndex, "pkg:npm/B@1.0.0")]; check && graph.Node(idB) != nil {
 			idB = b.id
 		} else {
 			t.Error("Node B-1.0.0 didn't exist")
@@ -130,43 +132,43 @@ func TestNodeCreationMediumComplexity(t *testing.T) {
 
 	//dummyMap := make(map[int64]NodeInfo)
 	graph := simple.NewDirectedGraph()
-	hashMap, nodeMap := CreateMaps(&mediumPackageInfo, graph)
-	hashToVersionMap := CreateHashedVersionMap(&mediumPackageInfo)
-	CreateEdges(graph, &mediumPackageInfo, hashMap, nodeMap, hashToVersionMap, false)
+	nodeIndex, nodeMap := CreateMaps(&mediumPackageInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&mediumPackageInfo)
+	CreateEdges(graph, &mediumPackageInfo, nodeIndex, nodeMap, versionIndex, NPM)
 
 	t.Run("Creates 8 nodes, one for every package version", func(t *testing.T) {
 
-		if numNodes := graph.Edges().Len(); numNodes != 8 {
-			t.Errorf("Expected 8 edges, got %d", numNodes)
+		if numNodes := graph.Nodes().Len(); numNodes != 8 {
+			t.Errorf("Expected 8 nodes, got %d", numNodes)
 		}
 
 	})
 
 	t.Run("Creates the 8 correct nodes", func(t *testing.T) {
 		packageIDS := []string{
-			"A-0.9.0",
-			"A-1.0.0-rc.1",
-			"A-1.0.0",
-			"A-1.1.0",
-			"A-2.0.0",
-			"B-1.0.0",
-			"C-1.0.0",
-			"C-2.0.0",
+			"pkg:npm/A@0.9.0",
+			"pkg:npm/A@1.0.0-rc.1",
+			"pkg:npm/A@1.0.0",
+			"pkg:npm/A@1.1.0",
+			"pkg:npm/A@2.0.0",
+			"pkg:npm/B@1.0.0",
+			"pkg:npm/C@1.0.0",
+			"pkg:npm/C@2.0.0",
 		}
 
 		testInfo := map[string]NodeInfo{
-			"A-0.9.0":      createTestNodeInfo(packageA, "0.9.0"),
-			"A-1.0.0-rc.1": createTestNodeInfo(packageA, "1.0.0-rc.1"),
-			"A-1.0.0":      createTestNodeInfo(packageA, "1.0.0"),
-			"A-1.1.0":      createTestNodeInfo(packageA, "1.1.0"),
-			"A-2.0.0":      createTestNodeInfo(packageA, "2.0.0"),
-			"B-1.0.0":      createTestNodeInfo(packageB, "1.0.0"),
-			"C-1.0.0":      createTestNodeInfo(packageC, "1.0.0"),
-			"C-2.0.0":      createTestNodeInfo(packageC, "2.0.0"),
+			"pkg:npm/A@0.9.0":      createTestNodeInfo(packageA, "0.9.0"),
+			"pkg:npm/A@1.0.0-rc.1": createTestNodeInfo(packageA, "1.0.0-rc.1"),
+			"pkg:npm/A@1.0.0":      createTestNodeInfo(packageA, "1.0.0"),
+			"pkg:npm/A@1.1.0":      createTestNodeInfo(packageA, "1.1.0"),
+			"pkg:npm/A@2.0.0":      createTestNodeInfo(packageA, "2.0.0"),
+			"pkg:npm/B@1.0.0":      createTestNodeInfo(packageB, "1.0.0"),
+			"pkg:npm/C@1.0.0":      createTestNodeInfo(packageC, "1.0.0"),
+			"pkg:npm/C@2.0.0":      createTestNodeInfo(packageC, "2.0.0"),
 		}
 
 		for _, v := range packageIDS {
-			if actual, ok := nodeMap[LookupByStringId(v, hashMap)]; !ok {
+			if actual, ok := nodeMap[nodeId(t, nodeIndex, v)]; !ok {
 				t.Errorf("Package version node %s not found", v)
 			} else {
 				expected := testInfo[v]
@@ -183,15 +185,16 @@ func TestNodeCreationMediumComplexity(t *testing.T) {
 }
 
 func nodeInfosEqual(expected, actual NodeInfo) bool {
-	return expected.Name == actual.Name && expected.Version == actual.Version && expected.Timestamp == actual.Timestamp
+	return expected.Name == actual.Name && expected.Version == actual.Version && expected.Timestamp.Equal(actual.Timestamp)
 }
 
 func createTestNodeInfo(pi PackageInfo, version string) NodeInfo {
+	timestamp, _ := NPM.ParseTimestamp(pi.Versions[version].Timestamp)
 	return NodeInfo{
 		id:        -1,
 		Name:      pi.Name,
 		Version:   version,
-		Timestamp: pi.Versions[version].Timestamp,
+		Timestamp: timestamp,
 	}
 }
 
@@ -220,9 +223,9 @@ func TestCreateEdgesBasicGraph(t *testing.T) {
 	}
 	//dummyMap := make(map[int64]NodeInfo)
 	graph := simple.NewDirectedGraph()
-	hashMap, nodeMap := CreateMaps(&simplePackagesInfo, graph)
-	hashToVersionMap := CreateHashedVersionMap(&simplePackagesInfo)
-	CreateEdges(graph, &simplePackagesInfo, hashMap, nodeMap, hashToVersionMap, false)
+	nodeIndex, nodeMap := CreateMaps(&simplePackagesInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&simplePackagesInfo)
+	CreateEdges(graph, &simplePackagesInfo, nodeIndex, nodeMap, versionIndex, NPM)
 
 	t.Run("Creates one edge when there is one dependency", func(t *testing.T) {
 
@@ -231,8 +234,8 @@ func TestCreateEdgesBasicGraph(t *testing.T) {
 		}
 	})
 	t.Run("Creates the edge with the correct direction (dependent -> dependency)", func(t *testing.T) {
-		fromID := nodeMap[LookupByStringId("B-1.0.0", hashMap)].id
-		toID := nodeMap[LookupByStringId("A-1.0.0", hashMap)].id
+		fromID := nodeMap[nodeId(t, nodeIndex, "pkg:npm/B@1.0.0")].id
+		toID := nodeMap[nodeId(t, nodeIndex, "pkg:npm/A@1.0.0")].id
 		if graph.Edge(fromID, toID) == nil {
 			if graph.Edge(toID, fromID) != nil {
 				t.Error("Expected the correct direction but got a reversed edge. Please check if the edge " +
@@ -293,23 +296,23 @@ func TestCreateEdgesMediumComplexityGraph(t *testing.T) {
 	}
 	//dummyMap := make(map[int64]NodeInfo)
 	graph := simple.NewDirectedGraph()
-	hashMap, nodeMap := CreateMaps(&packagesInfo, graph)
-	hashToVersionMap := CreateHashedVersionMap(&packagesInfo)
-	CreateEdges(graph, &packagesInfo, hashMap, nodeMap, hashToVersionMap, false)
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, NPM)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, NPM)
 	t.Run("Creates 4 edges when there are 4 possible dependencies", func(t *testing.T) {
 		if graph.Edges().Len() != 4 {
 			t.Errorf("Expected 4 edges, got %d", graph.Edges().Len())
 		}
 	})
 	t.Run("Creates edges to the correct dependencies for Node B-1.0.0", func(t *testing.T) {
-		if graph.From(nodeMap[LookupByStringId("B-1.0.0", hashMap)].id).Len() != 3 {
-			t.Errorf("Expected 3 possible dependencies for Node B-1.0.0, got %d", graph.From(nodeMap[LookupByStringId("B-1.0.0", hashMap)].id).Len())
+		if graph.From(nodeMap[nodeId(t, nodeIndex, "pkg:npm/B@1.0.0")].id).Len() != 3 {
+			t.Errorf("Expected 3 possible dependencies for Node B-1.0.0, got %d", graph.From(nodeMap[nodeId(t, nodeIndex, "pkg:npm/B@1.0.0")].id).Len())
 		}
-		nodesIterator := graph.From(nodeMap[LookupByStringId("B-1.0.0", hashMap)].id)
+		nodesIterator := graph.From(nodeMap[nodeId(t, nodeIndex, "pkg:npm/B@1.0.0")].id)
 		counter := 0
 		for nodesIterator.Next() {
 			currentNode := nodesIterator.Node()
-			if currentNode.ID() == graph.Node(nodeMap[LookupByStringId("C-1.0.0", hashMap)].id).ID() {
+			if currentNode.ID() == graph.Node(nodeMap[nodeId(t, nodeIndex, "pkg:npm/C@1.0.0")].id).ID() {
 				counter++
 			}
 		}
@@ -319,8 +322,73 @@ func TestCreateEdgesMediumComplexityGraph(t *testing.T) {
 
 	})
 	t.Run("Creates no edges from Node A-1.0.0 (it has no dependencies)", func(t *testing.T) {
-		if graph.From(nodeMap[LookupByStringId("A-1.0.0", hashMap)].id).Len() != 0 {
-			t.Errorf("Expected 0 dependencies for Node A-1.0.0, got %d", graph.From(nodeMap[LookupByStringId("A-1.0.0", hashMap)].id).Len())
+		if graph.From(nodeMap[nodeId(t, nodeIndex, "pkg:npm/A@1.0.0")].id).Len() != 0 {
+			t.Errorf("Expected 0 dependencies for Node A-1.0.0, got %d", graph.From(nodeMap[nodeId(t, nodeIndex, "pkg:npm/A@1.0.0")].id).Len())
 		}
 	})
 }
+
+func TestTimeWindowTraversalPrunesEdges(t *testing.T) {
+	date := func(year int) time.Time {
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	// Of the edges in test_data.json, only B-1.0.0 -> A-2.0.1 and C-1.0.0 -> A-0.9.0 lead to a dependency that was
+	// published before its dependent: B was published at the same moment as C, C at the same moment as A-1.0.0, and
+	// A-1.1.0 after B
+	cases := []struct {
+		name     string
+		filter   func(g *simple.DirectedGraph, nodes *NodeIndex, nodeMap map[int64]NodeInfo)
+		expected []string
+	}{
+		{"Every package from 2020 on", func(g *simple.DirectedGraph, _ *NodeIndex, nodeMap map[int64]NodeInfo) {
+			filterGraph(g, nodeMap, date(2020), date(2022))
+		}, []string{"B-1.0.0 -> A-2.0.1 ()", "C-1.0.0 -> A-0.9.0 ()"}},
+		{"Every package from 2021 on", func(g *simple.DirectedGraph, _ *NodeIndex, nodeMap map[int64]NodeInfo) {
+			filterGraph(g, nodeMap, date(2021), date(2022))
+		}, []string{"B-1.0.0 -> A-2.0.1 ()"}},
+		{"No package", func(g *simple.DirectedGraph, _ *NodeIndex, nodeMap map[int64]NodeInfo) {
+			filterGraph(g, nodeMap, date(2022), date(2023))
+		}, nil},
+		{"The tree of C, which removes the edges of B outside it", func(g *simple.DirectedGraph, nodes *NodeIndex, nodeMap map[int64]NodeInfo) {
+			FilterNode(g, nodes, nodeMap, PackageVersion{Ecosystem: "maven", Name: "C", Version: "1.0.0"}, date(2020), date(2022))
+		}, []string{"C-1.0.0 -> A-0.9.0 ()"}},
+		{"The tree of B, which includes C", func(g *simple.DirectedGraph, nodes *NodeIndex, nodeMap map[int64]NodeInfo) {
+			FilterNode(g, nodes, nodeMap, PackageVersion{Ecosystem: "maven", Name: "B", Version: "1.0.0"}, date(2020), date(2022))
+		}, []string{"B-1.0.0 -> A-2.0.1 ()"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			packagesInfo := ParseJSON("../data/input/test_data.json")
+			graph, nodes, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.filter(graph, nodes, nodeMap)
+			edges := edgeSet(graph, nodeMap)
+			if len(edges) != len(c.expected) {
+				t.Errorf("Expected the edges %v, got %v", c.expected, edges)
+			}
+			for _, edge := range c.expected {
+				if !edges[edge] {
+					t.Errorf("Expected the edge %s to survive, got %v", edge, edges)
+				}
+			}
+			if graph.Nodes().Len() != len(nodeMap) {
+				t.Errorf("Expected the graph to keep its %d nodes, got %d", len(nodeMap), graph.Nodes().Len())
+			}
+		})
+	}
+}
+
+func TestDependenciesOnly(t *testing.T) {
+	packageInfo := PackageInfo{Name: "app", Versions: map[string]VersionInfo{
+		"1.0.0": {Timestamp: "2021-01-01T00:00:00Z", Author: "Someone", Dependencies: map[string]string{"lib": "^1.0.0"},
+			Kinds: map[string]string{"lib": "dev"}},
+		"2.0.0": {Timestamp: "2022-01-01T00:00:00Z", Description: "Without dependencies"},
+	}}
+	kept := dependenciesOnly(packageInfo)
+	expected := VersionInfo{Dependencies: map[string]string{"lib": "^1.0.0"}, Kinds: map[string]string{"lib": "dev"}}
+	if kept.Name != "app" || len(kept.Versions) != 1 || !reflect.DeepEqual(kept.Versions["1.0.0"], expected) {
+		t.Errorf("Expected only the dependencies of app 1.0.0, got %+v", kept)
+	}
+}
@@ -0,0 +1,61 @@
+package graph
+
+import "fmt"
+
+// NodeIndex finds the node of a package version. Nodes are keyed by the package version itself, so distinct package
+// versions never share a key.
+type NodeIndex struct {
+	nodes map[PackageVersion]int64
+}
+
+// NewNodeIndex creates an empty index with room for size nodes.
+func NewNodeIndex(size int) *NodeIndex {
+	return &NodeIndex{nodes: make(map[PackageVersion]int64, size)}
+}
+
+// Add adds the node of a package version. It returns an error if the package version already has another node, since
+// the edges of the package version would otherwise be split between two nodes. Adding the same node again does nothing.
+func (index *NodeIndex) Add(key PackageVersion, id int64) error {
+	if existing, ok := index.nodes[key]; ok && existing != id {
+		return fmt.Errorf("package version %s already has node %d, not adding node %d", key, existing, id)
+	}
+	index.nodes[key] = id
+	return nil
+}
+
+// Lookup returns the node of a package version, and whether there is one.
+func (index *NodeIndex) Lookup(key PackageVersion) (int64, bool) {
+	id, ok := index.nodes[key]
+	return id, ok
+}
+
+// Len returns the amount of nodes in the index.
+func (index *NodeIndex) Len() int {
+	return len(index.nodes)
+}
+
+// VersionIndex finds the versions of a package. Packages are keyed by their name.
+type VersionIndex struct {
+	packages map[string][]string
+}
+
+// NewVersionIndex creates an empty index with room for size packages.
+func NewVersionIndex(size int) *VersionIndex {
+	return &VersionIndex{packages: make(map[string][]string, size)}
+}
+
+// Add adds versions to a package.
+func (index *VersionIndex) Add(name string, versions ...string) {
+	index.packages[name] = append(index.packages[name], versions...)
+}
+
+// Lookup returns the versions of a package, and whether the package is in the index.
+func (index *VersionIndex) Lookup(name string) ([]string, bool) {
+	versions, ok := index.packages[name]
+	return versions, ok
+}
+
+// Len returns the amount of packages in the index.
+func (index *VersionIndex) Len() int {
+	return len(index.packages)
+}
@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"hash/crc32"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// sliceSource is a PackageSource that returns the packages of a slice.
+type sliceSource struct {
+	packages []PackageInfo
+}
+
+func (s *sliceSource) Next() (PackageInfo, error) {
+	if len(s.packages) == 0 {
+		return PackageInfo{}, io.EOF
+	}
+	next := s.packages[0]
+	s.packages = s.packages[1:]
+	return next, nil
+}
+
+// nodeId returns the node of the package version with the package URL, and fails the test if there is none.
+func nodeId(t *testing.T, nodes *NodeIndex, purl string) int64 {
+	t.Helper()
+	key, err := ParsePackageVersion(purl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, ok := nodes.Lookup(key)
+	if !ok {
+		t.Fatalf("No node for %s", purl)
+	}
+	return id
+}
+
+func TestNodeIndexLookup(t *testing.T) {
+	index := NewNodeIndex(0)
+	a := PackageVersion{Ecosystem: "npm", Name: "a", Version: "1.0.0"}
+	index.Add(a, 0)
+	if id, ok := index.Lookup(a); !ok || id != 0 {
+		t.Errorf("Expected node 0, got %d, %v", id, ok)
+	}
+	if _, ok := index.Lookup(PackageVersion{Ecosystem: "npm", Name: "b", Version: "1.0.0"}); ok {
+		t.Error("Expected an unknown package version not to be found")
+	}
+	if _, ok := index.Lookup(PackageVersion{Ecosystem: "cargo", Name: "a", Version: "1.0.0"}); ok {
+		t.Error("Expected the package version of another ecosystem not to be found")
+	}
+	if err := index.Add(a, 0); err != nil {
+		t.Errorf("Expected adding the same node again to succeed, got %v", err)
+	}
+	if err := index.Add(a, 2); err == nil {
+		t.Error("Expected an error for another node of the same package version")
+	}
+	if id, ok := index.Lookup(a); !ok || id != 0 || index.Len() != 1 {
+		t.Errorf("Expected the package version to keep node 0, got %d, %v", id, ok)
+	}
+}
+
+func TestIndexKeysWithTheSameHash(t *testing.T) {
+	// plumless and buckeroo have the same CRC-32, which used to be the key of a package
+	if crc32.ChecksumIEEE([]byte("plumless")) != crc32.ChecksumIEEE([]byte("buckeroo")) {
+		t.Fatal("Expected plumless and buckeroo to have the same CRC-32")
+	}
+
+	versions := NewVersionIndex(0)
+	versions.Add("plumless", "1.0.0")
+	versions.Add("buckeroo", "2.0.0", "3.0.0")
+	versions.Add("plumless", "1.1.0")
+	if plumless, ok := versions.Lookup("plumless"); !ok || !reflect.DeepEqual(plumless, []string{"1.0.0", "1.1.0"}) {
+		t.Errorf("Expected versions 1.0.0 and 1.1.0 of plumless, got %v, %v", plumless, ok)
+	}
+	if buckeroo, ok := versions.Lookup("buckeroo"); !ok || !reflect.DeepEqual(buckeroo, []string{"2.0.0", "3.0.0"}) {
+		t.Errorf("Expected versions 2.0.0 and 3.0.0 of buckeroo, got %v, %v", buckeroo, ok)
+	}
+
+	nodes := NewNodeIndex(0)
+	plumless := PackageVersion{Ecosystem: "npm", Name: "plumless", Version: "1.0.0"}
+	buckeroo := PackageVersion{Ecosystem: "npm", Name: "buckeroo", Version: "1.0.0"}
+	nodes.Add(plumless, 0)
+	nodes.Add(buckeroo, 1)
+	if id, ok := nodes.Lookup(plumless); !ok || id != 0 {
+		t.Errorf("Expected node 0 for %s, got %d, %v", plumless, id, ok)
+	}
+	if id, ok := nodes.Lookup(buckeroo); !ok || id != 1 {
+		t.Errorf("Expected node 1 for %s, got %d, %v", buckeroo, id, ok)
+	}
+}
+
+func TestNodeIndexSimilarPackageVersions(t *testing.T) {
+	index := NewNodeIndex(0)
+	first := PackageVersion{Ecosystem: "npm", Name: "foo-1", Version: "2.0"}
+	second := PackageVersion{Ecosystem: "npm", Name: "foo", Version: "1-2.0"}
+	index.Add(first, 0)
+	index.Add(second, 1)
+	if id, ok := index.Lookup(second); !ok || id != 1 {
+		t.Errorf("Expected node 1 for %s, got %d, %v", second, id, ok)
+	}
+}
+
+func TestCreateGraphDuplicatePackage(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{Name: "a", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00Z"}}},
+		{Name: "a", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00Z"}, "2.0.0": {Timestamp: "2021-01-01T00:00:00Z"}}},
+	}
+	graph, nodes, _, versions, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, NPM, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Nodes().Len() != 2 || nodes.Len() != 2 {
+		t.Errorf("Expected 2 nodes, got %d in the graph and %d in the index", graph.Nodes().Len(), nodes.Len())
+	}
+	if aVersions, _ := versions.Lookup("a"); len(aVersions) != 2 {
+		t.Errorf("Expected 2 versions of a, got %v", aVersions)
+	}
+}
+
+func TestCreateGraphConflictingDuplicatePackage(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{Name: "a", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2020-01-01T00:00:00Z"}}},
+		{Name: "a", Versions: map[string]VersionInfo{"1.0.0": {Timestamp: "2022-01-01T00:00:00Z"}}},
+	}
+	if _, _, _, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, NPM, 1); err == nil {
+		t.Error("Expected an error for a package version that is read twice with different timestamps")
+	}
+}
@@ -0,0 +1,442 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mavenEcosystem orders versions the way Maven's ComparableVersion does and reads the version ranges of Maven.
+type mavenEcosystem struct{}
+
+func (mavenEcosystem) Name() string {
+	return "maven"
+}
+
+func (mavenEcosystem) NormalizeName(name string) string {
+	return name
+}
+
+func (mavenEcosystem) ParseVersion(version string) (Version, error) {
+	v, err := parseMavenVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (mavenEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	return parseMavenRange(constraint)
+}
+
+// Pick implements Picker. Maven resolves a dependency the nearest to the root first, and a soft requirement such as 1.0
+// to the version it names, so that version is picked if it exists. Ranges resolve to the highest version in them.
+func (mavenEcosystem) Pick(constraint string, candidates []Version) int {
+	r, err := parseMavenRange(constraint)
+	if err != nil || !r.soft {
+		return -1
+	}
+	for i, candidate := range candidates {
+		if candidate.Compare(r.intervals[0].lower.version) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (mavenEcosystem) ParseTimestamp(timestamp string) (time.Time, error) {
+	return parseTimestamp(timestamp, time.RFC3339, "2006-01-02T15:04:05", "20060102150405")
+}
+
+// PurlName uses the group ID as namespace and the artifact ID as name.
+func (mavenEcosystem) PurlName(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func (mavenEcosystem) ParsePurlName(namespace, name string) (string, error) {
+	if namespace == "" {
+		return name, nil
+	}
+	if strings.Contains(namespace, "/") {
+		return "", fmt.Errorf("maven group %q contains a slash", namespace)
+	}
+	return namespace + ":" + name, nil
+}
+
+// mavenItem is a part of a Maven version: a number, a qualifier or a list of items. A nil mavenItem stands for a
+// missing item when versions of different lengths are compared.
+type mavenItem interface {
+	compare(other mavenItem) int
+	isNull() bool
+}
+
+// mavenQualifiers are the well-known qualifiers from oldest to newest. A release has the empty qualifier, and unknown
+// qualifiers come after all of them, ordered alphabetically.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenQualifierAliases = map[string]string{"ga": "", "final": "", "release": "", "cr": "rc"}
+
+// mavenReleaseQualifier is the comparable form of the empty qualifier.
+var mavenReleaseQualifier = comparableQualifier("")
+
+func comparableQualifier(qualifier string) string {
+	for i, known := range mavenQualifiers {
+		if qualifier == known {
+			return strconv.Itoa(i)
+		}
+	}
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + qualifier
+}
+
+// mavenInt is a number of arbitrary size, stored as its digits without leading zeros.
+type mavenInt string
+
+func newMavenInt(digits string) mavenInt {
+	return mavenInt(strings.TrimLeft(digits, "0"))
+}
+
+func (i mavenInt) isNull() bool {
+	return i == ""
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		if len(i) != len(o) {
+			return compareInts(len(i), len(o))
+		}
+		return strings.Compare(string(i), string(o))
+	}
+	return 1 // 1.1 > 1-sp and 1.1 > 1-1
+}
+
+// mavenString is a qualifier, such as alpha or SNAPSHOT.
+type mavenString string
+
+func newMavenString(value string, followedByDigit bool) mavenString {
+	if followedByDigit && len(value) == 1 {
+		switch value {
+		case "a":
+			value = "alpha"
+		case "b":
+			value = "beta"
+		case "m":
+			value = "milestone"
+		}
+	}
+	if alias, ok := mavenQualifierAliases[value]; ok {
+		value = alias
+	}
+	return mavenString(value)
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(comparableQualifier(string(s)), mavenReleaseQualifier)
+	case mavenString:
+		return strings.Compare(comparableQualifier(string(s)), comparableQualifier(string(o)))
+	}
+	return -1 // 1-alpha < 1.1 and 1-alpha < 1-1
+}
+
+// mavenList is a list of items. Every dash, and every change between digits and letters, starts a sublist.
+type mavenList []mavenItem
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+func (l mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case mavenList:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var left, right mavenItem
+			if i < len(l) {
+				left = l[i]
+			}
+			if i < len(o) {
+				right = o[i]
+			}
+			var result int
+			if left == nil {
+				if right != nil {
+					result = -right.compare(nil)
+				}
+			} else {
+				result = left.compare(right)
+			}
+			if result != 0 {
+				return result
+			}
+		}
+	}
+	return 0
+}
+
+// normalize removes the trailing null items of the list, up to the last sublist, so that 1.0.0 equals 1.
+func (l *mavenList) normalize() {
+	for i := len(*l) - 1; i >= 0; i-- {
+		item := (*l)[i]
+		if item.isNull() {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+		} else if _, isList := item.(mavenList); !isList {
+			break
+		}
+	}
+}
+
+// mavenVersion is a version ordered the way Maven's ComparableVersion orders them, so that for example
+// 1-alpha-2 < 1.0-beta < 1-SNAPSHOT < 1 = 1.0.0 = 1-final < 1-sp < 1.0.1 < 1.1.
+type mavenVersion struct {
+	items   mavenList
+	literal string
+}
+
+// mavenListBuilder builds the nested lists of a version. Sublists are appended to their parent when they are
+// finished, since a mavenList is a slice and would otherwise be copied before its items are added.
+type mavenListBuilder struct {
+	items  mavenList
+	parent *mavenListBuilder
+}
+
+func (b *mavenListBuilder) finish() *mavenListBuilder {
+	b.items.normalize()
+	b.parent.items = append(b.parent.items, b.items)
+	return b.parent
+}
+
+func parseMavenVersion(version string) (*mavenVersion, error) {
+	literal := version
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version == "" {
+		return nil, errors.New("empty version")
+	}
+	if strings.ContainsAny(version, "[](),${} ") {
+		return nil, fmt.Errorf("invalid version %q", literal)
+	}
+
+	root := &mavenListBuilder{}
+	list := root
+	item := func(value string, isDigit, followedByDigit bool) mavenItem {
+		if isDigit {
+			return newMavenInt(value)
+		}
+		return newMavenString(value, followedByDigit)
+	}
+	startSublist := func() {
+		list = &mavenListBuilder{parent: list}
+	}
+
+	isDigit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.':
+			if i == start {
+				list.items = append(list.items, mavenInt(""))
+			} else {
+				list.items = append(list.items, item(version[start:i], isDigit, false))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				list.items = append(list.items, mavenInt(""))
+			} else {
+				list.items = append(list.items, item(version[start:i], isDigit, false))
+			}
+			start = i + 1
+			startSublist()
+		case c >= '0' && c <= '9':
+			if !isDigit && i > start {
+				list.items = append(list.items, newMavenString(version[start:i], true))
+				start = i
+				startSublist()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				list.items = append(list.items, newMavenInt(version[start:i]))
+				start = i
+				startSublist()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > start {
+		list.items = append(list.items, item(version[start:], isDigit, false))
+	}
+	for list != root {
+		list = list.finish()
+	}
+	root.items.normalize()
+	return &mavenVersion{items: root.items, literal: literal}, nil
+}
+
+// Compare implements Version.
+func (v *mavenVersion) Compare(other Version) int {
+	return v.items.compare(other.(*mavenVersion).items)
+}
+
+func (v *mavenVersion) String() string {
+	return v.literal
+}
+
+// mavenBound is one end of a range. A nil version means the range is unbounded on that side.
+type mavenBound struct {
+	version   *mavenVersion
+	inclusive bool
+}
+
+// mavenInterval is a single range such as [1.0,2.0) or [1.5].
+type mavenInterval struct {
+	lower, upper mavenBound
+}
+
+func (i mavenInterval) contains(v *mavenVersion) bool {
+	if i.lower.version != nil {
+		c := v.Compare(i.lower.version)
+		if c < 0 || c == 0 && !i.lower.inclusive {
+			return false
+		}
+	}
+	if i.upper.version != nil {
+		c := v.Compare(i.upper.version)
+		if c > 0 || c == 0 && !i.upper.inclusive {
+			return false
+		}
+	}
+	return true
+}
+
+// mavenRange is a dependency version as Maven reads it: either a set of ranges, of which a version has to be in at
+// least one, or a soft requirement such as 1.0. Maven can pick any version for a soft requirement when resolving
+// conflicts, and the versions it would pick are the required one and newer ones, so a soft requirement matches those.
+type mavenRange struct {
+	intervals []mavenInterval
+	soft      bool // Whether the range is a soft requirement, whose single interval starts at the required version
+}
+
+// Check implements Constraint.
+func (r mavenRange) Check(v Version) bool {
+	version, ok := v.(*mavenVersion)
+	if !ok {
+		return false
+	}
+	for _, interval := range r.intervals {
+		if interval.contains(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMavenRange parses a Maven version specification, such as 1.0, [1.0], [1.2,1.3), (,1.0],[1.2,) or [1.5,).
+// Unspecified versions and the LATEST and RELEASE meta versions match every version.
+func parseMavenRange(spec string) (mavenRange, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "", "unspecified", "LATEST", "RELEASE":
+		return mavenRange{intervals: []mavenInterval{{}}}, nil
+	}
+	if !strings.ContainsAny(spec, "[(") {
+		v, err := parseMavenVersion(spec)
+		if err != nil {
+			return mavenRange{}, err
+		}
+		return mavenRange{intervals: []mavenInterval{{lower: mavenBound{v, true}}}, soft: true}, nil
+	}
+
+	var r mavenRange
+	rest := spec
+	for rest != "" {
+		if rest[0] != '[' && rest[0] != '(' {
+			return mavenRange{}, fmt.Errorf("invalid range %q: expected [ or ( at %q", spec, rest)
+		}
+		end := strings.IndexAny(rest, "])")
+		if end < 0 {
+			return mavenRange{}, fmt.Errorf("invalid range %q: unbounded range", spec)
+		}
+		interval, err := parseMavenInterval(rest[:end+1])
+		if err != nil {
+			return mavenRange{}, fmt.Errorf("invalid range %q: %w", spec, err)
+		}
+		r.intervals = append(r.intervals, interval)
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest != "" {
+			if rest[0] != ',' {
+				return mavenRange{}, fmt.Errorf("invalid range %q: expected , at %q", spec, rest)
+			}
+			rest = strings.TrimSpace(rest[1:])
+			if rest == "" {
+				return mavenRange{}, fmt.Errorf("invalid range %q: trailing comma", spec)
+			}
+		}
+	}
+	return r, nil
+}
+
+// parseMavenInterval parses a single range, including its brackets.
+func parseMavenInterval(s string) (mavenInterval, error) {
+	lowerInclusive := s[0] == '['
+	upperInclusive := s[len(s)-1] == ']'
+	bounds := strings.Split(s[1:len(s)-1], ",")
+	parseBound := func(bound string, inclusive bool) (mavenBound, error) {
+		bound = strings.TrimSpace(bound)
+		if bound == "" {
+			return mavenBound{}, nil
+		}
+		v, err := parseMavenVersion(bound)
+		return mavenBound{version: v, inclusive: inclusive}, err
+	}
+
+	switch len(bounds) {
+	case 1: // [1.0] only matches 1.0
+		if !lowerInclusive || !upperInclusive {
+			return mavenInterval{}, fmt.Errorf("single version %s has to be in square brackets", s)
+		}
+		bound, err := parseBound(bounds[0], true)
+		if err != nil || bound.version == nil {
+			return mavenInterval{}, fmt.Errorf("invalid version in %s", s)
+		}
+		return mavenInterval{lower: bound, upper: bound}, nil
+	case 2:
+		lower, err := parseBound(bounds[0], lowerInclusive)
+		if err != nil {
+			return mavenInterval{}, err
+		}
+		upper, err := parseBound(bounds[1], upperInclusive)
+		if err != nil {
+			return mavenInterval{}, err
+		}
+		if lower.version != nil && upper.version != nil && lower.version.Compare(upper.version) > 0 {
+			return mavenInterval{}, fmt.Errorf("lower bound of %s is above its upper bound", s)
+		}
+		return mavenInterval{lower: lower, upper: upper}, nil
+	}
+	return mavenInterval{}, fmt.Errorf("%s has more than two bounds", s)
+}
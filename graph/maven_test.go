@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestMavenVersionOrdering(t *testing.T) {
+	// The orderings Maven's own ComparableVersionTest checks
+	orderings := [][]string{
+		{"1-alpha2snapshot", "1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc", "1-cr2",
+			"1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def", "1-pom-1", "1-1-snapshot",
+			"1-1", "1-2", "1-123"},
+		{"2.0", "2-1", "2.0.a", "2.0.0.a", "2.0.2", "2.0.123", "2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1", "2.1.0.1",
+			"2.2", "2.123", "11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11", "11.a", "11b", "11c", "11m"},
+		{"9.0", "10.0", "99999999999999999999", "100000000000000000000"},
+	}
+	for _, ordered := range orderings {
+		for i := 0; i+1 < len(ordered); i++ {
+			a, err1 := parseMavenVersion(ordered[i])
+			b, err2 := parseMavenVersion(ordered[i+1])
+			if err1 != nil || err2 != nil {
+				t.Fatalf("Could not parse %s or %s: %v %v", ordered[i], ordered[i+1], err1, err2)
+			}
+			if a.Compare(b) >= 0 || b.Compare(a) <= 0 {
+				t.Errorf("Expected %s < %s", ordered[i], ordered[i+1])
+			}
+		}
+	}
+
+	equal := [][]string{
+		{"1", "1.0", "1.0.0", "1-ga", "1.ga", "1-final", "1.0.0.Final", "1-RELEASE", "1-0", "1.0-0"},
+		{"1a1", "1-a1", "1-alpha-1", "1-ALPHA-1"},
+		{"1cr", "1rc", "1.0-CR"},
+	}
+	for _, versions := range equal {
+		first, _ := parseMavenVersion(versions[0])
+		for _, version := range versions[1:] {
+			v, err := parseMavenVersion(version)
+			if err != nil || first.Compare(v) != 0 {
+				t.Errorf("Expected %s == %s (%v)", versions[0], version, err)
+			}
+		}
+	}
+}
+
+func TestMavenRanges(t *testing.T) {
+	cases := []struct {
+		spec    string
+		matches []string
+		rejects []string
+	}{
+		{"1.0", []string{"1.0", "1.0.1", "2.0-SNAPSHOT"}, []string{"0.9", "1.0-alpha-1"}},
+		{"[1.0]", []string{"1.0", "1.0.0", "1.0.Final"}, []string{"1.0.1", "1.0-SNAPSHOT"}},
+		{"[1.2,1.3)", []string{"1.2", "1.2.99", "1.3-SNAPSHOT"}, []string{"1.1", "1.3"}},
+		{"(,1.0],[1.2,)", []string{"0.5", "1.0", "1.2", "10.0"}, []string{"1.1", "1.0.1"}},
+		{"(1.0,2.0]", []string{"1.0.1", "2.0", "2.0.Final"}, []string{"1.0", "2.0.1"}},
+		{"[ 1.5 , )", []string{"1.5", "5.0.0-M1"}, []string{"1.4"}},
+		{"RELEASE", []string{"0.1", "100"}, nil},
+		{"unspecified", []string{"0.1"}, nil},
+	}
+	for _, c := range cases {
+		r, err := parseMavenRange(c.spec)
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", c.spec, err)
+			continue
+		}
+		for _, version := range c.matches {
+			if v, err := parseMavenVersion(version); err != nil || !r.Check(v) {
+				t.Errorf("Expected %q to match %s", c.spec, version)
+			}
+		}
+		for _, version := range c.rejects {
+			if v, err := parseMavenVersion(version); err != nil || r.Check(v) {
+				t.Errorf("Expected %q to reject %s", c.spec, version)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"(1.0)", "[1.0", "[2.0,1.0]", "[1,2,3]", "[1.0],", "[1.0]x", "${project.version}"} {
+		if _, err := parseMavenRange(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestCreateEdgesMaven(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "org.example:app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {
+					Timestamp: "2021-01-01T00:00:00",
+					Dependencies: map[string]string{
+						"org.hibernate:hibernate-core": "[5.4,6.0)",
+						"com.google.guava:guava":       "[31.1-jre]",
+					},
+				},
+			},
+		},
+		{
+			Name: "org.hibernate:hibernate-core",
+			Versions: map[string]VersionInfo{
+				"5.3.20.Final": {Timestamp: "2020-01-01T00:00:00"},
+				"5.4.32.Final": {Timestamp: "2020-06-01T00:00:00"},
+				"6.0.0.Final":  {Timestamp: "2022-01-01T00:00:00"},
+			},
+		},
+		{
+			Name: "com.google.guava:guava",
+			Versions: map[string]VersionInfo{
+				"31.0-jre": {Timestamp: "2020-01-01T00:00:00"},
+				"31.1-jre": {Timestamp: "2020-06-01T00:00:00"},
+			},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, Maven)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, Maven)
+
+	from := nodeId(t, nodeIndex, "pkg:maven/org.example/app@1.0.0")
+	if edges := graph.From(from).Len(); edges != 2 {
+		t.Errorf("Expected 2 edges, got %d", edges)
+	}
+	for _, dependency := range []string{"pkg:maven/org.hibernate/hibernate-core@5.4.32.Final", "pkg:maven/com.google.guava/guava@31.1-jre"} {
+		if graph.Edge(from, nodeId(t, nodeIndex, dependency)) == nil {
+			t.Errorf("Expected an edge to %s", dependency)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PackageVersion identifies a single version of a package. Unlike a name-version string, it cannot be ambiguous:
+// package foo-1 at version 2.0 and package foo at version 1-2.0 are different keys. Ecosystem is the name of the
+// ecosystem, which is also the type of its package URLs.
+type PackageVersion struct {
+	Ecosystem string
+	Name      string
+	Version   string
+}
+
+// String encodes the package version as a package URL (purl), such as pkg:maven/org.apache/commons@1.2. The name is
+// split into the namespace and name of the URL by the ecosystem, or at its last slash if the ecosystem is not
+// registered. Every part is percent-encoded, so ParsePackageVersion can always decode it back to the same package
+// version. Without a version, the URL refers to the package as a whole.
+func (p PackageVersion) String() string {
+	namespace, name := splitPurlPath(p.Name)
+	if ecosystem, ok := LookupEcosystem(p.Ecosystem); ok {
+		namespace, name = ecosystem.PurlName(p.Name)
+	}
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(p.Ecosystem)
+	b.WriteString("/")
+	if namespace != "" {
+		for _, segment := range strings.Split(namespace, "/") {
+			b.WriteString(escapePurl(segment))
+			b.WriteString("/")
+		}
+	}
+	b.WriteString(escapePurl(name))
+	if p.Version != "" {
+		b.WriteString("@")
+		b.WriteString(escapePurl(p.Version))
+	}
+	return b.String()
+}
+
+// ParsePackageVersion decodes a package URL that refers to a package version, such as pkg:npm/%40babel/core@7.0.0, or
+// to a package as a whole, such as pkg:npm/%40babel/core, which gives a package version without a version. Qualifiers and subpaths, like the ?type=jar of some Maven URLs, are accepted and ignored, since they do not change
+// which package version is meant. The type is lowercased, and the ecosystem it refers to turns the namespace and
+// name into the name of the package, normalised the way the ecosystem normalises names. Types of ecosystems that are
+// not registered are accepted too, with the namespace and name joined by a slash.
+func ParsePackageVersion(s string) (PackageVersion, error) {
+	rest := strings.TrimSpace(s)
+	if len(rest) < 4 || !strings.EqualFold(rest[:4], "pkg:") {
+		return PackageVersion{}, fmt.Errorf("package URL %q does not start with pkg:", s)
+	}
+	rest = strings.TrimLeft(rest[4:], "/")
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest = rest[:i]
+	}
+	rest = strings.TrimRight(rest, "/")
+
+	slash := strings.IndexByte(rest, '/')
+	at := strings.LastIndexByte(rest, '@')
+	if at < strings.LastIndexByte(rest, '/') { // An @ before the name starts an unescaped npm scope, not a version
+		at = len(rest)
+	}
+	if slash <= 0 || at == len(rest)-1 {
+		return PackageVersion{}, fmt.Errorf("package URL %q is not of the form pkg:type/namespace/name@version", s)
+	}
+	key := PackageVersion{Ecosystem: strings.ToLower(rest[:slash])}
+	if at < len(rest) {
+		version, err := url.PathUnescape(rest[at+1:])
+		if err != nil {
+			return PackageVersion{}, fmt.Errorf("invalid version in package URL %q: %w", s, err)
+		}
+		key.Version = version
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(rest[slash+1:at], "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return PackageVersion{}, fmt.Errorf("invalid name in package URL %q: %w", s, err)
+		}
+		segments = append(segments, unescaped)
+	}
+	if len(segments) == 0 {
+		return PackageVersion{}, fmt.Errorf("package URL %q has no name", s)
+	}
+	namespace, name := strings.Join(segments[:len(segments)-1], "/"), segments[len(segments)-1]
+	key.Name = joinPurlPath(namespace, name)
+	if ecosystem, ok := LookupEcosystem(key.Ecosystem); ok {
+		var err error
+		if key.Name, err = ecosystem.ParsePurlName(namespace, name); err != nil {
+			return PackageVersion{}, fmt.Errorf("invalid package URL %q: %w", s, err)
+		}
+	}
+	return key, nil
+}
+
+// escapePurl percent-encodes every byte of s except letters, digits and the characters . - _ ~.
+func escapePurl(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte(".-_~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
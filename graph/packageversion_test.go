@@ -0,0 +1,69 @@
+package graph
+
+import "testing"
+
+func TestPackageVersionString(t *testing.T) {
+	tests := []struct {
+		key      PackageVersion
+		expected string
+	}{
+		{PackageVersion{"npm", "left-pad", "1.3.0"}, "pkg:npm/left-pad@1.3.0"},
+		{PackageVersion{"npm", "@babel/core", "7.0.0-beta.1"}, "pkg:npm/%40babel/core@7.0.0-beta.1"},
+		{PackageVersion{"golang", "github.com/BurntSushi/toml", "v1.2.0"}, "pkg:golang/github.com/BurntSushi/toml@v1.2.0"},
+		{PackageVersion{"cargo", "serde", "1.0.0+build.1"}, "pkg:cargo/serde@1.0.0%2Bbuild.1"},
+		{PackageVersion{"maven", "org.example:app", "1.0"}, "pkg:maven/org.example/app@1.0"},
+		{PackageVersion{"pypi", "django-rest-framework", "3.0"}, "pkg:pypi/django-rest-framework@3.0"},
+		{PackageVersion{"conan", "some/thing", "1.0"}, "pkg:conan/some/thing@1.0"},
+		{PackageVersion{"npm", "foo-1", "2.0"}, "pkg:npm/foo-1@2.0"},
+		{PackageVersion{"npm", "foo", "1-2.0"}, "pkg:npm/foo@1-2.0"},
+		{PackageVersion{"npm", "left-pad", ""}, "pkg:npm/left-pad"},
+		{PackageVersion{"npm", "@babel/core", ""}, "pkg:npm/%40babel/core"},
+		{PackageVersion{"maven", "org.example:app", ""}, "pkg:maven/org.example/app"},
+	}
+	for _, test := range tests {
+		if actual := test.key.String(); actual != test.expected {
+			t.Errorf("Expected %+v to encode as %s, got %s", test.key, test.expected, actual)
+		}
+		parsed, err := ParsePackageVersion(test.expected)
+		if err != nil {
+			t.Errorf("Could not parse %s: %v", test.expected, err)
+		} else if parsed != test.key {
+			t.Errorf("Expected %s to parse as %+v, got %+v", test.expected, test.key, parsed)
+		}
+	}
+}
+
+func TestParsePackageVersionNormalisation(t *testing.T) {
+	tests := map[string]PackageVersion{
+		"pkg:npm/@babel/core@7.0.0":                    {"npm", "@babel/core", "7.0.0"},
+		"pkg:npm/babel/core@7.0.0":                     {"npm", "@babel/core", "7.0.0"},
+		"PKG:NPM/left-pad@1.3.0":                       {"npm", "left-pad", "1.3.0"},
+		"pkg://npm/left-pad@1.3.0/":                    {"npm", "left-pad", "1.3.0"},
+		"pkg:maven/org.apache/commons@1.2?type=jar":    {"maven", "org.apache:commons", "1.2"},
+		"pkg:maven/org.apache/commons@1.2#src/main":    {"maven", "org.apache:commons", "1.2"},
+		"pkg:pypi/Django_Rest.Framework@3.0":           {"pypi", "django-rest-framework", "3.0"},
+		"pkg:golang/github.com/BurntSushi/toml@v1.2.0": {"golang", "github.com/BurntSushi/toml", "v1.2.0"},
+		"pkg:golang/golang.org%2Fx%2Ftext@v0.3.7":      {"golang", "golang.org/x/text", "v0.3.7"},
+		"pkg:cargo/serde@1.0.0%2Bbuild.1?repository=x": {"cargo", "serde", "1.0.0+build.1"},
+		"pkg:npm/@babel/core":                          {"npm", "@babel/core", ""},
+		"pkg:maven/org.apache/commons?type=jar":        {"maven", "org.apache:commons", ""},
+	}
+	for purl, expected := range tests {
+		parsed, err := ParsePackageVersion(purl)
+		if err != nil {
+			t.Errorf("Could not parse %s: %v", purl, err)
+		} else if parsed != expected {
+			t.Errorf("Expected %s to parse as %+v, got %+v", purl, expected, parsed)
+		}
+	}
+}
+
+func TestParsePackageVersionErrors(t *testing.T) {
+	invalid := []string{"", "left-pad-1.3.0", "pkg:npm", "pkg:npm/@1.0.0", "pkg:npm/left-pad@", "pkg:/left-pad@1.0.0",
+		"pkg:npm/%zz@1.0.0", "pkg:npm/a/b/c@1.0.0", "pkg:pypi/org/django@3.0", "pkg:cargo/rust-lang/serde@1.0.0"}
+	for _, invalid := range invalid {
+		if parsed, err := ParsePackageVersion(invalid); err == nil {
+			t.Errorf("Expected %q not to parse, got %+v", invalid, parsed)
+		}
+	}
+}
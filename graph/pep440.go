@@ -0,0 +1,463 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pep440Pattern is the version pattern of PEP 440, see https://peps.python.org/pep-0440/#appendix-b-parsing-version-strings-with-regular-expressions
+var pep440Pattern = regexp.MustCompile(`(?i)^v?(?:(?:(?P<epoch>[0-9]+)!)?(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?P<pre>[-_\.]?(?P<pre_l>a|b|c|rc|alpha|beta|pre|preview)[-_\.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?P<post>(?:-(?P<post_n1>[0-9]+))|(?:[-_\.]?(?P<post_l>post|rev|r)[-_\.]?(?P<post_n2>[0-9]+)?))?` +
+	`(?P<dev>[-_\.]?(?P<dev_l>dev)[-_\.]?(?P<dev_n>[0-9]+)?)?)(?:\+(?P<local>[a-z0-9]+(?:[-_\.][a-z0-9]+)*))?$`)
+
+// pep508Pattern splits a PEP 508 requirement into its name, extras, version specifier or URL, and marker.
+var pep508Pattern = regexp.MustCompile(`^\s*(?P<name>[A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*` +
+	`(?:\[(?P<extras>[^\]]*)\])?\s*(?:@\s*(?P<url>[^;\s]+)\s*|(?P<spec>[^;]*))(?:;\s*(?P<marker>.*))?$`)
+
+var pep503Separators = regexp.MustCompile(`[-_.]+`)
+
+// The order of the pre-release phases
+var pep440PreReleases = map[string]int{"a": 0, "b": 1, "rc": 2}
+
+// pep440Version is a version as defined by PEP 440. The post and dev releases are -1 when absent.
+type pep440Version struct {
+	epoch   int
+	release []int
+	pre     string // "a", "b", "rc" or empty
+	preN    int
+	post    int
+	dev     int
+	local   []string
+	literal string
+}
+
+// parsePEP440Version parses and normalises a PEP 440 version.
+func parsePEP440Version(s string) (*pep440Version, error) {
+	s = strings.TrimSpace(s)
+	match := pep440Pattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, fmt.Errorf("invalid PEP 440 version %q", s)
+	}
+	group := func(name string) string {
+		return match[pep440Pattern.SubexpIndex(name)]
+	}
+	atoi := func(value string) int {
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+
+	v := &pep440Version{post: -1, dev: -1, literal: s}
+	v.epoch = atoi(group("epoch"))
+	for _, part := range strings.Split(group("release"), ".") {
+		v.release = append(v.release, atoi(part))
+	}
+	if label := strings.ToLower(group("pre_l")); label != "" {
+		switch label {
+		case "alpha":
+			label = "a"
+		case "beta":
+			label = "b"
+		case "c", "pre", "preview":
+			label = "rc"
+		}
+		v.pre = label
+		v.preN = atoi(group("pre_n"))
+	}
+	if group("post") != "" {
+		v.post = atoi(group("post_n1") + group("post_n2"))
+	}
+	if group("dev") != "" {
+		v.dev = atoi(group("dev_n"))
+	}
+	if local := group("local"); local != "" {
+		v.local = pep440Separators(strings.ToLower(local))
+	}
+	return v, nil
+}
+
+func pep440Separators(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+}
+
+func (v *pep440Version) isPreRelease() bool {
+	return v.pre != "" || v.dev >= 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareRelease compares release segments, padding the shorter one with zeros.
+func compareRelease(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareLocal compares local version labels. A version without a label sorts before one with a label, numeric
+// segments sort after alphanumeric ones.
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, xErr := strconv.Atoi(a[i])
+		y, yErr := strconv.Atoi(b[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if c := compareInts(x, y); c != 0 {
+				return c
+			}
+		case xErr == nil:
+			return 1
+		case yErr == nil:
+			return -1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+// phase orders the pre-release part of a version: development releases of a final release come before its
+// pre-releases, which come before the final release.
+func (v *pep440Version) phase() (int, int) {
+	switch {
+	case v.pre == "" && v.post < 0 && v.dev >= 0:
+		return -1, 0
+	case v.pre == "":
+		return len(pep440PreReleases), 0
+	}
+	return pep440PreReleases[v.pre], v.preN
+}
+
+// compare orders versions the way PEP 440 specifies.
+func (v *pep440Version) compare(o *pep440Version) int {
+	if c := compareInts(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+	if c := compareRelease(v.release, o.release); c != 0 {
+		return c
+	}
+	vPhase, vN := v.phase()
+	oPhase, oN := o.phase()
+	if c := compareInts(vPhase, oPhase); c != 0 {
+		return c
+	}
+	if c := compareInts(vN, oN); c != 0 {
+		return c
+	}
+	if c := compareInts(v.post, o.post); c != 0 {
+		return c
+	}
+	// A development release comes before the release it belongs to
+	vDev, oDev := v.dev, o.dev
+	if vDev < 0 {
+		vDev = int(^uint(0) >> 1)
+	}
+	if oDev < 0 {
+		oDev = int(^uint(0) >> 1)
+	}
+	if c := compareInts(vDev, oDev); c != 0 {
+		return c
+	}
+	return compareLocal(v.local, o.local)
+}
+
+// Compare implements Version.
+func (v *pep440Version) Compare(other Version) int {
+	return v.compare(other.(*pep440Version))
+}
+
+func (v *pep440Version) String() string {
+	return v.literal
+}
+
+// public returns the version without its local label.
+func (v *pep440Version) public() *pep440Version {
+	public := *v
+	public.local = nil
+	return &public
+}
+
+// pep440Clause is a single comparison of a specifier, such as >=1.0 or !=1.5.*.
+type pep440Clause struct {
+	operator string
+	version  *pep440Version
+	prefix   bool // Whether the version ended with .* and should be prefix matched
+	literal  string
+}
+
+// pep440Specifier is a comma-separated set of clauses that all have to match.
+type pep440Specifier struct {
+	clauses     []pep440Clause
+	prereleases bool // Pre-releases only match if one of the clauses mentions one
+}
+
+var pep440Operators = []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+// parsePEP440Specifier parses a version specifier. It also accepts the remainder of a PEP 508 requirement after the
+// name: extras, parentheses and environment markers are dropped, since the graph does not model environments. An
+// empty specifier or * matches every version.
+func parsePEP440Specifier(s string) (*pep440Specifier, error) {
+	if i := strings.Index(s, ";"); i >= 0 { // Environment marker
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") { // Extras
+		if i := strings.Index(s, "]"); i >= 0 {
+			s = strings.TrimSpace(s[i+1:])
+		}
+	}
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"))
+
+	specifier := &pep440Specifier{}
+	if s == "" || s == "*" {
+		return specifier, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		clause, err := parsePEP440Clause(part)
+		if err != nil {
+			return nil, err
+		}
+		if clause.version != nil && clause.version.isPreRelease() && clause.operator != "!=" {
+			specifier.prereleases = true
+		}
+		specifier.clauses = append(specifier.clauses, clause)
+	}
+	return specifier, nil
+}
+
+func parsePEP440Clause(s string) (pep440Clause, error) {
+	clause := pep440Clause{operator: "=="} // A bare version is treated as an exact match
+	for _, operator := range pep440Operators {
+		if strings.HasPrefix(s, operator) {
+			clause.operator = operator
+			s = strings.TrimSpace(s[len(operator):])
+			break
+		}
+	}
+	clause.literal = s
+	if clause.operator == "===" {
+		return clause, nil
+	}
+	if strings.HasSuffix(s, ".*") {
+		if clause.operator != "==" && clause.operator != "!=" {
+			return clause, fmt.Errorf("wildcard not allowed with %s in %q", clause.operator, s)
+		}
+		clause.prefix = true
+		s = strings.TrimSuffix(s, ".*")
+	}
+	version, err := parsePEP440Version(s)
+	if err != nil {
+		return clause, err
+	}
+	if clause.operator == "~=" && len(version.release) < 2 {
+		return clause, errors.New("~= needs a version with at least two release segments")
+	}
+	clause.version = version
+	return clause, nil
+}
+
+// check reports whether the version matches every clause of the specifier.
+func (s *pep440Specifier) check(v *pep440Version) bool {
+	if v.isPreRelease() && !s.prereleases {
+		return false
+	}
+	for _, clause := range s.clauses {
+		if !clause.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check implements Constraint.
+func (s *pep440Specifier) Check(v Version) bool {
+	version, ok := v.(*pep440Version)
+	return ok && s.check(version)
+}
+
+// prefixMatch reports whether v starts with the release of prefix, as ==1.5.* requires.
+func prefixMatch(v, prefix *pep440Version) bool {
+	if v.epoch != prefix.epoch {
+		return false
+	}
+	for i, segment := range prefix.release {
+		var actual int
+		if i < len(v.release) {
+			actual = v.release[i]
+		}
+		if actual != segment {
+			return false
+		}
+	}
+	return true
+}
+
+func (c pep440Clause) check(v *pep440Version) bool {
+	switch c.operator {
+	case "===":
+		return strings.EqualFold(v.literal, c.literal)
+	case "==", "!=":
+		var equal bool
+		if c.prefix {
+			equal = prefixMatch(v, c.version)
+		} else if len(c.version.local) == 0 {
+			equal = v.public().compare(c.version) == 0
+		} else {
+			equal = v.compare(c.version) == 0
+		}
+		return equal == (c.operator == "==")
+	case "~=":
+		prefix := &pep440Version{epoch: c.version.epoch, release: c.version.release[:len(c.version.release)-1]}
+		return v.public().compare(c.version) >= 0 && prefixMatch(v, prefix)
+	case "<=":
+		return v.public().compare(c.version) <= 0
+	case ">=":
+		return v.public().compare(c.version) >= 0
+	case "<":
+		// <1.0 does not match pre-releases of 1.0, unless the clause itself is a pre-release
+		if v.public().compare(c.version) >= 0 {
+			return false
+		}
+		return c.version.isPreRelease() || !v.isPreRelease() || compareRelease(v.release, c.version.release) != 0
+	case ">":
+		// >1.0 does not match post-releases or local versions of 1.0, unless the clause itself is a post-release
+		if v.public().compare(c.version) <= 0 {
+			return false
+		}
+		if c.version.post < 0 && v.post >= 0 && compareRelease(v.release, c.version.release) == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// Requirement is a parsed PEP 508 dependency specification, such as requests[security] (>=2.8.1) ; python_version<"3".
+type Requirement struct {
+	Name      string
+	Extras    []string
+	Specifier string
+	URL       string
+	Marker    string
+}
+
+// ParseRequirement parses a PEP 508 requirement. The name is normalised as described in PEP 503.
+func ParseRequirement(s string) (Requirement, error) {
+	match := pep508Pattern.FindStringSubmatch(s)
+	if match == nil {
+		return Requirement{}, fmt.Errorf("invalid PEP 508 requirement %q", s)
+	}
+	group := func(name string) string {
+		return strings.TrimSpace(match[pep508Pattern.SubexpIndex(name)])
+	}
+
+	requirement := Requirement{
+		Name:   NormalizePyPIName(group("name")),
+		URL:    group("url"),
+		Marker: group("marker"),
+	}
+	for _, extra := range strings.Split(group("extras"), ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			requirement.Extras = append(requirement.Extras, NormalizePyPIName(extra))
+		}
+	}
+	spec := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(group("spec"), "("), ")"))
+	if spec != "" {
+		if _, err := parsePEP440Specifier(spec); err != nil {
+			return Requirement{}, err
+		}
+	}
+	requirement.Specifier = spec
+	return requirement, nil
+}
+
+// OnlyForExtra reports whether the marker restricts the requirement to an extra, which means it is not installed
+// unless that extra is requested.
+func (r Requirement) OnlyForExtra() bool {
+	return strings.Contains(strings.ReplaceAll(r.Marker, " ", ""), "extra==")
+}
+
+// Constraint returns the version constraint of the requirement in the form it is stored in VersionInfo: the
+// specifier, or * if there is none, followed by the marker if there is one.
+func (r Requirement) Constraint() string {
+	constraint := r.Specifier
+	if constraint == "" {
+		constraint = "*"
+	}
+	if r.Marker != "" {
+		constraint += " ; " + r.Marker
+	}
+	return constraint
+}
+
+// NormalizePyPIName normalises a Python package name as described in PEP 503.
+func NormalizePyPIName(name string) string {
+	return strings.ToLower(pep503Separators.ReplaceAllString(strings.TrimSpace(name), "-"))
+}
+
+// pypiEcosystem uses PEP 440 versions and specifiers, and the PEP 503 normalised form of project names.
+type pypiEcosystem struct{}
+
+func (pypiEcosystem) Name() string {
+	return "pypi"
+}
+
+func (pypiEcosystem) NormalizeName(name string) string {
+	return NormalizePyPIName(name)
+}
+
+func (pypiEcosystem) ParseVersion(version string) (Version, error) {
+	v, err := parsePEP440Version(version)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (pypiEcosystem) ParseConstraint(constraint string) (Constraint, error) {
+	specifier, err := parsePEP440Specifier(constraint)
+	if err != nil {
+		return nil, err
+	}
+	return specifier, nil
+}
+
+func (pypiEcosystem) ParseTimestamp(timestamp string) (time.Time, error) {
+	return parseTimestamp(timestamp, time.RFC3339, "2006-01-02T15:04:05")
+}
+
+func (pypiEcosystem) PurlName(name string) (string, string) {
+	return "", name
+}
+
+// ParsePurlName normalises the name like PyPI does, so pkg:pypi/Django_Rest@1.0 finds django-rest.
+func (e pypiEcosystem) ParsePurlName(namespace, name string) (string, error) {
+	return withoutPurlNamespace(e, namespace, name)
+}
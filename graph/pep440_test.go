@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestPEP440Ordering(t *testing.T) {
+	ordered := []string{
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+		"1!0.5",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, err1 := parsePEP440Version(ordered[i])
+		b, err2 := parsePEP440Version(ordered[i+1])
+		if err1 != nil || err2 != nil {
+			t.Fatalf("Could not parse %s or %s: %v %v", ordered[i], ordered[i+1], err1, err2)
+		}
+		if a.compare(b) >= 0 || b.compare(a) <= 0 {
+			t.Errorf("Expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+
+	equal := [][2]string{{"1.0", "1.0.0"}, {"1.0alpha1", "1.0a1"}, {"1.0-1", "1.0.post1"}, {"v2.0RC1", "2.0rc1"}}
+	for _, pair := range equal {
+		a, _ := parsePEP440Version(pair[0])
+		b, _ := parsePEP440Version(pair[1])
+		if a.compare(b) != 0 {
+			t.Errorf("Expected %s == %s", pair[0], pair[1])
+		}
+	}
+}
+
+func TestPEP440Specifiers(t *testing.T) {
+	cases := []struct {
+		specifier string
+		matches   []string
+		rejects   []string
+	}{
+		{"~=1.4", []string{"1.4", "1.4.5", "1.9"}, []string{"1.3", "2.0", "1.5rc1"}},
+		{"~=1.4.2", []string{"1.4.2", "1.4.9"}, []string{"1.5", "1.4.1"}},
+		{"!=1.5.*", []string{"1.4", "1.6", "1.50"}, []string{"1.5", "1.5.3", "1.5.0.post1"}},
+		{"==1.5.*", []string{"1.5", "1.5.3"}, []string{"1.6", "1.50"}},
+		{">=1.0,<2.0", []string{"1.0", "1.0.post1", "1.9.9"}, []string{"0.9", "2.0", "2.0rc1", "1.5rc1"}},
+		{"<2.0", []string{"1.9"}, []string{"2.0rc1", "2.0.dev1"}},
+		{">1.0", []string{"1.1"}, []string{"1.0.post1", "1.0+local"}},
+		{">=2.0rc1", []string{"2.0rc1", "2.0", "2.1b1"}, []string{"2.0b3"}},
+		{"==1.0", []string{"1.0", "1.0.0", "1.0+local"}, []string{"1.0.post1"}},
+		{"<12.1dev,>=12.0a", []string{"12.0", "12.0.1.0.2", "12.0a1"}, []string{"12.1", "11.0"}},
+		{"*", []string{"0.1", "100"}, []string{"1.0rc1"}},
+		{"", []string{"0.1"}, nil},
+		{"(>=2.8.1) ; python_version < \"3\"", []string{"2.8.1"}, []string{"2.8"}},
+		{"[security]>=2.0", []string{"2.0"}, []string{"1.0"}},
+	}
+	for _, c := range cases {
+		specifier, err := parsePEP440Specifier(c.specifier)
+		if err != nil {
+			t.Errorf("Could not parse %q: %v", c.specifier, err)
+			continue
+		}
+		for _, version := range c.matches {
+			if v, err := parsePEP440Version(version); err != nil || !specifier.check(v) {
+				t.Errorf("Expected %q to match %s", c.specifier, version)
+			}
+		}
+		for _, version := range c.rejects {
+			if v, err := parsePEP440Version(version); err != nil || specifier.check(v) {
+				t.Errorf("Expected %q to reject %s", c.specifier, version)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"~=1", ">=1.5.*", ">=banana"} {
+		if _, err := parsePEP440Specifier(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestParseRequirement(t *testing.T) {
+	requirement, err := ParseRequirement(`Requests[Security, socks] (>=2.8.1,<3) ; python_version < "3.8"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requirement.Name != "requests" || len(requirement.Extras) != 2 || requirement.Specifier != ">=2.8.1,<3" ||
+		requirement.Marker != `python_version < "3.8"` {
+		t.Errorf("Unexpected requirement %+v", requirement)
+	}
+	if requirement.OnlyForExtra() {
+		t.Error("Expected the requirement not to be restricted to an extra")
+	}
+
+	extra, err := ParseRequirement(`pytest>=6; extra == 'test'`)
+	if err != nil || !extra.OnlyForExtra() {
+		t.Errorf("Expected the requirement to be restricted to an extra, got %+v (%v)", extra, err)
+	}
+
+	url, err := ParseRequirement(`pip @ https://github.com/pypa/pip/archive/1.3.1.zip#sha1=da9234ee9982d4bbb3c72346a6de940a148ea686`)
+	if err != nil || url.URL == "" || url.Constraint() != "*" {
+		t.Errorf("Unexpected URL requirement %+v (%v)", url, err)
+	}
+}
+
+func TestCreateEdgesPyPI(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "odoo12-addon-sale",
+			Versions: map[string]VersionInfo{
+				"12.0.1.0.2": {
+					Timestamp: "2021-03-04T06:10:54",
+					Dependencies: map[string]string{
+						"odoo":      "<12.1dev,>=12.0a",
+						"Termcolor": "~=1.1 ; python_version >= \"3\"",
+					},
+				},
+			},
+		},
+		{
+			Name: "odoo",
+			Versions: map[string]VersionInfo{
+				"11.0":       {Timestamp: "2019-01-01T00:00:00"},
+				"12.0":       {Timestamp: "2020-01-01T00:00:00"},
+				"12.0.post1": {Timestamp: "2020-02-01T00:00:00"},
+				"12.1":       {Timestamp: "2021-01-01T00:00:00"},
+			},
+		},
+		{
+			Name: "termcolor",
+			Versions: map[string]VersionInfo{
+				"1.1.0":    {Timestamp: "2019-01-01T00:00:00"},
+				"1.2.0rc1": {Timestamp: "2020-01-01T00:00:00"},
+				"2.0.0":    {Timestamp: "2021-01-01T00:00:00"},
+			},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, PyPI)
+	versionIndex := CreateVersionIndex(&packagesInfo)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, versionIndex, PyPI)
+
+	from := nodeMap[nodeId(t, nodeIndex, "pkg:pypi/odoo12-addon-sale@12.0.1.0.2")].id
+	for _, dependency := range []string{"pkg:pypi/odoo@12.0", "pkg:pypi/odoo@12.0.post1", "pkg:pypi/termcolor@1.1.0"} {
+		if graph.Edge(from, nodeMap[nodeId(t, nodeIndex, dependency)].id) == nil {
+			t.Errorf("Expected an edge to %s", dependency)
+		}
+	}
+	if edges := graph.From(from).Len(); edges != 3 {
+		t.Errorf("Expected 3 edges, got %d", edges)
+	}
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// ResolvedDependency is a package version in the dependency tree returned by ResolveAt, with the versions its
+// dependencies resolved to. A package version that is reached more than once is resolved once and shared, so the tree
+// can contain the same ResolvedDependency in several places, and a dependency cycle leads back to an ancestor.
+type ResolvedDependency struct {
+	NodeInfo
+	Dependencies []*ResolvedDependency // Sorted by package name
+}
+
+// ResolveAt resolves the dependencies of a package version the way its package manager would have on the given date:
+// every dependency resolves to the highest of the versions that match its constraint and were published on or before
+// the date, ordered by the ecosystem, and the dependencies of that version are resolved in turn. When the ecosystem
+// picks another version, such as Maven for a soft requirement, the edge to that version is the one valid on the date,
+// and it is followed instead. The versions that match a constraint are the targets of the edges created by
+// CreateEdges, so the ecosystem's rules for matching apply as well; for Go modules, which only link to the exact
+// version they require, that version is picked if it was published by then. Versions without a timestamp are never
+// picked. An error is returned if the package version is not in g or had not been published yet on the date. A
+// dependency none of whose matching versions had been published is left out of the tree.
+func ResolveAt(g graph.Directed, nodeMap map[int64]NodeInfo, nodes *NodeIndex, ecosystem Ecosystem, key PackageVersion, date time.Time) (*ResolvedDependency, error) {
+	id, ok := nodes.Lookup(key)
+	if !ok || g.Node(id) == nil {
+		return nil, fmt.Errorf("package version %s was not found", key)
+	}
+	root := nodeMap[id]
+	if root.Timestamp.IsZero() || root.Timestamp.After(date) {
+		return nil, fmt.Errorf("package version %s had not been published on %s", key, date.Format("2006-01-02"))
+	}
+
+	resolver := pointInTimeResolver{g: g, nodeMap: nodeMap, ecosystem: ecosystem, date: date,
+		resolved: make(map[int64]*ResolvedDependency), versions: make(map[int64]Version)}
+	return resolver.resolve(id), nil
+}
+
+type pointInTimeResolver struct {
+	g         graph.Directed
+	nodeMap   map[int64]NodeInfo
+	ecosystem Ecosystem
+	date      time.Time
+	resolved  map[int64]*ResolvedDependency
+	versions  map[int64]Version // Parsed versions, nil for versions the ecosystem cannot parse
+}
+
+// resolve returns the resolved dependency of the node, resolving its dependencies first if it has not been resolved
+// yet. The node is recorded before its dependencies are resolved, so cycles end at the node.
+func (r *pointInTimeResolver) resolve(id int64) *ResolvedDependency {
+	if resolved, ok := r.resolved[id]; ok {
+		return resolved
+	}
+	resolved := &ResolvedDependency{NodeInfo: r.nodeMap[id]}
+	r.resolved[id] = resolved
+
+	// The targets of the edges of a package version that belong to the same package are the versions that match the
+	// constraint of one dependency
+	candidates := make(map[string]resolutionCandidate)
+	targets := r.g.From(id)
+	for targets.Next() {
+		candidate := resolutionCandidate{id: targets.Node().ID()}
+		info := r.nodeMap[candidate.id]
+		if info.Timestamp.IsZero() || info.Timestamp.After(r.date) {
+			continue
+		}
+		if valid, ok := edgeValidity(r.g.Edge(id, candidate.id)); ok {
+			candidate.current = valid.Contains(r.date)
+		}
+		best, ok := candidates[info.Name]
+		if !ok || candidate.current && !best.current || candidate.current == best.current && r.higher(candidate.id, best.id) {
+			candidates[info.Name] = candidate
+		}
+	}
+
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resolved.Dependencies = append(resolved.Dependencies, r.resolve(candidates[name].id))
+	}
+	return resolved
+}
+
+// resolutionCandidate is a version that a dependency can resolve to, with whether the edge to it is valid on the date.
+type resolutionCandidate struct {
+	id      int64
+	current bool
+}
+
+// higher reports whether the version of node a is higher than the version of node b. Versions the ecosystem can parse
+// are higher than versions it cannot, which are compared as strings.
+func (r *pointInTimeResolver) higher(a, b int64) bool {
+	return compareParsedVersions(r.version(a), r.version(b), r.nodeMap[a].Version, r.nodeMap[b].Version) > 0
+}
+
+func (r *pointInTimeResolver) version(id int64) Version {
+	if version, ok := r.versions[id]; ok {
+		return version
+	}
+	version, err := r.ecosystem.ParseVersion(r.nodeMap[id].Version)
+	if err != nil {
+		version = nil
+	}
+	r.versions[id] = version
+	return version
+}
+
+// Flatten returns every package version in the tree once, in breadth-first order starting with the root.
+func (d *ResolvedDependency) Flatten() []NodeInfo {
+	var result []NodeInfo
+	seen := map[*ResolvedDependency]bool{d: true}
+	queue := []*ResolvedDependency{d}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		result = append(result, current.NodeInfo)
+		for _, dependency := range current.Dependencies {
+			if !seen[dependency] {
+				seen[dependency] = true
+				queue = append(queue, dependency)
+			}
+		}
+	}
+	return result
+}
+
+// String draws the tree with one package version per line, indented by its depth. Like npm ls, the dependencies of a
+// package version are only drawn the first time it appears, and later appearances are marked as deduped.
+func (d *ResolvedDependency) String() string {
+	var b strings.Builder
+	seen := make(map[*ResolvedDependency]bool)
+	var draw func(dependency *ResolvedDependency, depth int)
+	draw = func(dependency *ResolvedDependency, depth int) {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(dependency.NodeInfo.String())
+		if seen[dependency] {
+			if len(dependency.Dependencies) > 0 {
+				b.WriteString(" (deduped)")
+			}
+			b.WriteString("\n")
+			return
+		}
+		seen[dependency] = true
+		b.WriteString("\n")
+		for _, child := range dependency.Dependencies {
+			draw(child, depth+1)
+		}
+	}
+	draw(d, 0)
+	return b.String()
+}
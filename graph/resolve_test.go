@@ -0,0 +1,172 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestResolveAt(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-06-01T00:00:00Z", Dependencies: map[string]string{"lib": "^1.0.0", "missing": "*"}},
+			},
+		},
+		{
+			Name: "lib",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-01-01T00:00:00Z"},
+				"1.1.0": {Timestamp: "2021-03-01T00:00:00Z", Dependencies: map[string]string{"util": "*"}},
+				"1.2.0": {Timestamp: "2021-09-01T00:00:00Z"},
+				"2.0.0": {Timestamp: "2021-02-01T00:00:00Z"},
+			},
+		},
+		{
+			Name: "util",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2020-01-01T00:00:00Z"},
+				"2.0.0": {Timestamp: "2021-05-01T00:00:00Z", Dependencies: map[string]string{"lib": "1.x"}},
+				"3.0.0": {Timestamp: "not a timestamp"},
+			},
+		},
+	}
+	graph := simple.NewDirectedGraph()
+	nodeIndex, nodeMap := CreateMaps(&packagesInfo, graph, NPM)
+	CreateEdges(graph, &packagesInfo, nodeIndex, nodeMap, CreateVersionIndex(&packagesInfo), NPM)
+	app, _ := ParsePackageVersion("pkg:npm/app@1.0.0")
+
+	t.Run("Picks the highest version published by the date", func(t *testing.T) {
+		resolved, err := ResolveAt(graph, nodeMap, nodeIndex, NPM, app, time.Date(2021, 6, 15, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := strings.Join([]string{
+			"pkg:npm/app@1.0.0",
+			"  pkg:npm/lib@1.1.0",
+			"    pkg:npm/util@2.0.0",
+			"      pkg:npm/lib@1.1.0 (deduped)",
+			"",
+		}, "\n")
+		if tree := resolved.String(); tree != expected {
+			t.Errorf("Expected the tree\n%s\ngot\n%s", expected, tree)
+		}
+		if flat := resolved.Flatten(); len(flat) != 3 || flat[0].Name != "app" || flat[2].Name != "util" {
+			t.Errorf("Expected app, lib and util, got %v", flat)
+		}
+	})
+
+	t.Run("Resolves to newer versions later on", func(t *testing.T) {
+		resolved, err := ResolveAt(graph, nodeMap, nodeIndex, NPM, app, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resolved.Dependencies) != 1 || resolved.Dependencies[0].Version != "1.2.0" || len(resolved.Dependencies[0].Dependencies) != 0 {
+			t.Errorf("Expected app to resolve lib to 1.2.0 without dependencies, got\n%s", resolved)
+		}
+	})
+
+	t.Run("Skips versions published after the date and versions without a timestamp", func(t *testing.T) {
+		lib, _ := ParsePackageVersion("pkg:npm/lib@1.1.0")
+		resolved, err := ResolveAt(graph, nodeMap, nodeIndex, NPM, lib, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resolved.Dependencies) != 1 || resolved.Dependencies[0].Version != "1.0.0" {
+			t.Errorf("Expected lib to resolve util to 1.0.0, got\n%s", resolved)
+		}
+	})
+
+	t.Run("Rejects package versions that were not published yet", func(t *testing.T) {
+		if _, err := ResolveAt(graph, nodeMap, nodeIndex, NPM, app, time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC)); err == nil {
+			t.Error("Expected an error for a package version published after the date")
+		}
+		unknown := PackageVersion{Ecosystem: "npm", Name: "app", Version: "9.9.9"}
+		if _, err := ResolveAt(graph, nodeMap, nodeIndex, NPM, unknown, time.Now()); err == nil {
+			t.Error("Expected an error for an unknown package version")
+		}
+	})
+}
+
+func TestResolveAtOrdersVersionsByEcosystem(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	graph, nodes, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ParsePackageVersion("pkg:maven/B@1.0.0")
+
+	// A 1.1.0 is published a day after B, and A 2.0.1 a month before it
+	resolved, err := ResolveAt(graph, nodeMap, nodes, Maven, b, time.Date(2021, 4, 22, 21, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "pkg:maven/B@1.0.0\n  pkg:maven/A@2.0.1\n  pkg:maven/C@1.0.0\n    pkg:maven/A@1.0.0\n"
+	if tree := resolved.String(); tree != expected {
+		t.Errorf("Expected the tree\n%s\ngot\n%s", expected, tree)
+	}
+}
+
+func TestResolveAtKeepsMavenSoftRequirements(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "org.example:app",
+			Versions: map[string]VersionInfo{
+				"1.0": {Timestamp: "2021-06-01T00:00:00Z", Dependencies: map[string]string{"org.example:lib": "1.0", "org.example:util": "[1.0,)"}},
+				"2.0": {Timestamp: "2021-06-01T00:00:00Z", Dependencies: map[string]string{"org.example:lib": "1.5"}},
+			},
+		},
+		{
+			Name: "org.example:lib",
+			Versions: map[string]VersionInfo{
+				"1.0": {Timestamp: "2020-01-01T00:00:00Z"},
+				"1.1": {Timestamp: "2021-01-01T00:00:00Z"},
+				"2.0": {Timestamp: "2021-03-01T00:00:00Z"},
+			},
+		},
+		{
+			Name: "org.example:util",
+			Versions: map[string]VersionInfo{
+				"1.0": {Timestamp: "2020-01-01T00:00:00Z"},
+				"1.1": {Timestamp: "2021-01-01T00:00:00Z"},
+			},
+		},
+	}
+	graph, nodes, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		purl     string
+		expected string
+	}{
+		// The soft requirement resolves to the version it names, the range to the highest version in it
+		{"pkg:maven/org.example/app@1.0", "pkg:maven/org.example/app@1.0\n  pkg:maven/org.example/lib@1.0\n  pkg:maven/org.example/util@1.1\n"},
+		// Without a version 1.5, the soft requirement resolves to the highest newer version
+		{"pkg:maven/org.example/app@2.0", "pkg:maven/org.example/app@2.0\n  pkg:maven/org.example/lib@2.0\n"},
+	}
+	// The edges valid on the date are the ones to the versions the dependencies resolved to
+	resolvedEdges := make(map[string]bool)
+	for _, c := range cases {
+		key, _ := ParsePackageVersion(c.purl)
+		resolved, err := ResolveAt(graph, nodeMap, nodes, Maven, key, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree := resolved.String(); tree != c.expected {
+			t.Errorf("Expected the tree\n%s\ngot\n%s", c.expected, tree)
+		}
+		for _, dependency := range resolved.Dependencies {
+			resolvedEdges[c.purl+" -> "+dependency.NodeInfo.String()] = true
+		}
+	}
+	if edges := viewEdges(t, ValidView(graph, date, date), nodeMap); !reflect.DeepEqual(edges, resolvedEdges) {
+		t.Errorf("Expected the edges valid on the date to be %v, got %v", resolvedEdges, edges)
+	}
+}
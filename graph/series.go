@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Metric scores every node of a graph.
+type Metric func(g graph.Directed) map[int64]float64
+
+// Metrics are the metrics a Series can be created for, by name.
+var Metrics = map[string]Metric{
+	"pagerank":    PageRank,
+	"betweenness": Betweenness,
+	"dependents":  Dependents,
+}
+
+// Dependents scores every node with the amount of package versions that depend on it directly.
+func Dependents(g graph.Directed) map[int64]float64 {
+	result := make(map[int64]float64)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		id := nodes.Node().ID()
+		result[id] = float64(g.To(id).Len())
+	}
+	return result
+}
+
+// Step is the time between two slices of a Series.
+type Step struct {
+	Years, Months, Days int
+}
+
+// ParseStep parses a step written as a positive number followed by d, w, m or y, such as 1m for monthly slices or 2w
+// for slices every two weeks.
+func ParseStep(s string) (Step, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return Step{}, fmt.Errorf("invalid step %q, expected a number followed by d, w, m or y", s)
+	}
+	amount, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || amount <= 0 {
+		return Step{}, fmt.Errorf("invalid step %q, expected a positive number followed by d, w, m or y", s)
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return Step{Days: amount}, nil
+	case 'w':
+		return Step{Days: 7 * amount}, nil
+	case 'm':
+		return Step{Months: amount}, nil
+	case 'y':
+		return Step{Years: amount}, nil
+	}
+	return Step{}, fmt.Errorf("invalid step %q, expected a number followed by d, w, m or y", s)
+}
+
+// Next returns the time one step after t.
+func (s Step) Next(t time.Time) time.Time {
+	return s.After(t, 1)
+}
+
+// After returns the time n steps after t. The steps are added at once, so unlike calling Next n times, a month that is
+// cut short does not shift the steps after it: monthly steps from January 31st give March 3rd and then March 31st.
+func (s Step) After(t time.Time, n int) time.Time {
+	return t.AddDate(n*s.Years, n*s.Months, n*s.Days)
+}
+
+// Series holds the value of a metric for every package, at every slice of time.
+type Series struct {
+	Metric string
+	Times  []time.Time
+	// Values holds the value at every time of every package that had been published by the last one. The packages are
+	// package versions without a version. The value of a package is the sum of the values of its versions, and it is
+	// zero before its first version was published.
+	Values map[PackageVersion][]float64
+}
+
+// CreateSeries computes the metric on the graph as it was at start, and every step after that up to and including
+// end. The graph as it was at a moment has the package versions published on or before it and the edges between them
+// that were current at that moment, according to their Validity, so every dependency leads to the version it resolved
+// to then. Edges without a validity are always current. Since every slice only adds package versions to the previous
+// one, the slices are built incrementally: the package versions published since the previous slice are added, together
+// with their edges to and from the package versions that are already there, and the metric is computed on the
+// ValidView of the slice at the moment. Package versions without a timestamp are never added.
+func CreateSeries(g graph.Directed, nodeMap map[int64]NodeInfo, metricName string, start, end time.Time, step Step) (*Series, error) {
+	metric, ok := Metrics[metricName]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", metricName)
+	}
+	if end.Before(start) {
+		return nil, errors.New("the end of the series lies before its start")
+	}
+	if !step.Next(start).After(start) {
+		return nil, errors.New("the step of the series has to be positive")
+	}
+
+	// The nodes in the order they were published, which is the order they are added in
+	published := make([]int64, 0, len(nodeMap))
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if id := nodes.Node().ID(); !nodeMap[id].Timestamp.IsZero() {
+			published = append(published, id)
+		}
+	}
+	sort.Slice(published, func(i, j int) bool {
+		return nodeMap[published[i]].Timestamp.Before(nodeMap[published[j]].Timestamp)
+	})
+
+	series := &Series{Metric: metricName, Values: make(map[PackageVersion][]float64)}
+	slice := simple.NewDirectedGraph()
+	added := 0
+	for i := 0; !step.After(start, i).After(end); i++ {
+		t := step.After(start, i)
+		for ; added < len(published) && !nodeMap[published[added]].Timestamp.After(t); added++ {
+			addSliceNode(g, slice, published[added])
+		}
+		values := make(map[PackageVersion]float64)
+		if added > 0 {
+			for id, value := range metric(ValidView(slice, t, t)) {
+				info := nodeMap[id]
+				values[PackageVersion{Ecosystem: info.Ecosystem, Name: info.Name}] += value
+			}
+		}
+		for pkg, value := range values {
+			if _, ok := series.Values[pkg]; !ok {
+				series.Values[pkg] = make([]float64, len(series.Times), len(series.Times)+1)
+			}
+			series.Values[pkg] = append(series.Values[pkg], value)
+		}
+		series.Times = append(series.Times, t)
+		for pkg, pkgValues := range series.Values {
+			if len(pkgValues) < len(series.Times) { // Packages none of whose versions the metric scored
+				series.Values[pkg] = append(pkgValues, 0)
+			}
+		}
+	}
+	return series, nil
+}
+
+// addSliceNode adds the node to the slice, with its edges in g to and from the nodes that are in the slice already.
+// The edges are the edges of g themselves, so they keep their validity.
+func addSliceNode(g graph.Directed, slice *simple.DirectedGraph, id int64) {
+	slice.AddNode(simple.Node(id))
+	dependencies := g.From(id)
+	for dependencies.Next() {
+		if to := dependencies.Node().ID(); to != id && slice.Node(to) != nil {
+			slice.SetEdge(g.Edge(id, to))
+		}
+	}
+	dependents := g.To(id)
+	for dependents.Next() {
+		if from := dependents.Node().ID(); from != id && slice.Node(from) != nil {
+			slice.SetEdge(g.Edge(from, id))
+		}
+	}
+}
+
+// Top returns the n packages with the highest values, ordered by their highest value over all times, or every package
+// if n is not positive. Packages with the same highest value are ordered by name.
+func (s *Series) Top(n int) []PackageVersion {
+	highest := make(map[PackageVersion]float64, len(s.Values))
+	packages := make([]PackageVersion, 0, len(s.Values))
+	for pkg, values := range s.Values {
+		for _, value := range values {
+			if value > highest[pkg] {
+				highest[pkg] = value
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if highest[packages[i]] != highest[packages[j]] {
+			return highest[packages[i]] > highest[packages[j]]
+		}
+		return packages[i].Name < packages[j].Name
+	})
+	if n > 0 && n < len(packages) {
+		packages = packages[:n]
+	}
+	return packages
+}
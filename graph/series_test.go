@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStep(t *testing.T) {
+	start := time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC)
+	for input, expected := range map[string]time.Time{
+		"1d": time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+		"2w": time.Date(2021, 2, 14, 0, 0, 0, 0, time.UTC),
+		"1m": time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC),
+		"1Y": time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		step, err := ParseStep(input)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", input, err)
+		} else if next := step.Next(start); !next.Equal(expected) {
+			t.Errorf("Expected %q to step to %v, got %v", input, expected, next)
+		}
+	}
+	for _, input := range []string{"", "m", "0m", "-1d", "1h", "1.5y"} {
+		if _, err := ParseStep(input); err == nil {
+			t.Errorf("Expected %q not to parse", input)
+		}
+	}
+}
+
+func TestCreateSeries(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	graph, _, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	monthly, _ := ParseStep("1m")
+
+	t.Run("Dependents", func(t *testing.T) {
+		series, err := CreateSeries(graph, nodeMap, "dependents", start, end, monthly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(series.Times) != 3 || !series.Times[2].Equal(end) {
+			t.Fatalf("Expected three monthly slices, got %v", series.Times)
+		}
+		// Only versions of A were published before April 22nd; B and C are padded with zeros until then. By May 1st, the
+		// dependencies of B and C on A resolve to A 2.0.1 and A 1.0.0, so the edges to A 1.1.0 and A 0.9.0 do not count
+		expected := map[PackageVersion][]float64{
+			{Ecosystem: "maven", Name: "A"}: {0, 0, 2},
+			{Ecosystem: "maven", Name: "B"}: {0, 0, 0},
+			{Ecosystem: "maven", Name: "C"}: {0, 0, 1},
+		}
+		if !reflect.DeepEqual(series.Values, expected) {
+			t.Errorf("Expected %v, got %v", expected, series.Values)
+		}
+		if top := series.Top(2); len(top) != 2 || top[0].Name != "A" || top[1].Name != "C" {
+			t.Errorf("Expected A and C to be the top packages, got %v", top)
+		}
+	})
+
+	t.Run("The last slice is the graph at the end", func(t *testing.T) {
+		Metrics["exact pagerank"] = exactPageRank
+		defer delete(Metrics, "exact pagerank")
+		series, err := CreateSeries(graph, nodeMap, "exact pagerank", start, end, monthly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := make(map[string]float64)
+		for id, rank := range exactPageRank(ValidView(graph, end, end)) {
+			expected[nodeMap[id].Name] += rank
+		}
+		for pkg, values := range series.Values {
+			if math.Abs(values[2]-expected[pkg.Name]) > 1e-6 {
+				t.Errorf("Expected %s to have rank %f, got %f", pkg.Name, expected[pkg.Name], values[2])
+			}
+		}
+	})
+
+	t.Run("Monthly steps do not drift", func(t *testing.T) {
+		series, err := CreateSeries(graph, nodeMap, "dependents", time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC), end, monthly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []time.Time{
+			time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 3, 3, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if !reflect.DeepEqual(series.Times, expected) {
+			t.Errorf("Expected the times %v, got %v", expected, series.Times)
+		}
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		if _, err := CreateSeries(graph, nodeMap, "unknown", start, end, monthly); err == nil {
+			t.Error("Expected an unknown metric to fail")
+		}
+		if _, err := CreateSeries(graph, nodeMap, "dependents", end, start, monthly); err == nil {
+			t.Error("Expected an end before the start to fail")
+		}
+		if _, err := CreateSeries(graph, nodeMap, "dependents", start, end, Step{}); err == nil {
+			t.Error("Expected a step of zero to fail")
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// SnapshotVersion is the version of the snapshot format. It has to be increased whenever the format, or anything that
+// is stored in it, changes, so that older snapshots are rebuilt instead of being read wrongly.
+const SnapshotVersion uint32 = 1
+
+var snapshotMagic = []byte("STMGRAPH")
+
+// ErrStaleSnapshot is returned by ReadSnapshot when a snapshot was made with another version of the format, for
+// another ecosystem or from a source with another checksum. The graph has to be created again.
+var ErrStaleSnapshot = errors.New("snapshot is out of date")
+
+// Snapshot holds everything CreateGraph creates, so that it can be saved and loaded without creating the edges again.
+type Snapshot struct {
+	Graph       *simple.DirectedGraph
+	Nodes       *NodeIndex
+	NodeInfo    map[int64]NodeInfo
+	Versions    *VersionIndex
+	Diagnostics *Diagnostics
+}
+
+// snapshotHeader is written before the contents of a snapshot, followed by the name of the ecosystem, so that a stale
+// snapshot is detected without reading the rest of it.
+type snapshotHeader struct {
+	Version         uint32
+	Checksum        [32]byte // The SHA-256 checksum of the source
+	EcosystemLength uint16
+}
+
+type snapshotNode struct {
+	Id                       int64
+	Ecosystem, Name, Version string
+	Timestamp                time.Time
+	Metadata                 *Metadata
+}
+
+type snapshotEdge struct {
+	From, To int64
+	Kind     string
+	Valid    Validity
+}
+
+// snapshotContents is the part of a snapshot after its header. The indices are not stored, since they can be created
+// again from the nodes, with the versions of every package in the order they had. The unparsable versions that the
+// diagnostics have reported are stored separately, since gob leaves out unexported fields.
+type snapshotContents struct {
+	Nodes              []snapshotNode
+	Versions           []snapshotPackage
+	Edges              []snapshotEdge
+	Diagnostics        *Diagnostics
+	UnparsableVersions []string
+}
+
+type snapshotPackage struct {
+	Name     string
+	Versions []string
+}
+
+// WriteSnapshot writes the snapshot of a graph of the ecosystem, created from a source with the checksum.
+func WriteSnapshot(w io.Writer, snapshot *Snapshot, ecosystem Ecosystem, checksum [32]byte) error {
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.Write(snapshotMagic); err != nil {
+		return err
+	}
+	header := snapshotHeader{Version: SnapshotVersion, Checksum: checksum, EcosystemLength: uint16(len(ecosystem.Name()))}
+	if err := binary.Write(buffered, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := buffered.WriteString(ecosystem.Name()); err != nil {
+		return err
+	}
+
+	if snapshot.Diagnostics == nil {
+		return errors.New("the snapshot has no diagnostics")
+	}
+	contents := snapshotContents{Diagnostics: snapshot.Diagnostics}
+	for version := range snapshot.Diagnostics.unparsableVersions {
+		contents.UnparsableVersions = append(contents.UnparsableVersions, version)
+	}
+	contents.Nodes = make([]snapshotNode, 0, len(snapshot.NodeInfo))
+	for id, info := range snapshot.NodeInfo {
+		contents.Nodes = append(contents.Nodes, snapshotNode{id, info.Ecosystem, info.Name, info.Version, info.Timestamp, info.Metadata})
+	}
+	contents.Versions = make([]snapshotPackage, 0, snapshot.Versions.Len())
+	for name, versions := range snapshot.Versions.packages {
+		contents.Versions = append(contents.Versions, snapshotPackage{name, versions})
+	}
+	edges := snapshot.Graph.Edges()
+	contents.Edges = make([]snapshotEdge, 0, edges.Len())
+	for edges.Next() {
+		edge, ok := edges.Edge().(GraphEdge)
+		if !ok {
+			return fmt.Errorf("edge %d -> %d is a %T, not a GraphEdge", edges.Edge().From().ID(), edges.Edge().To().ID(), edges.Edge())
+		}
+		contents.Edges = append(contents.Edges, snapshotEdge{edge.FId, edge.TId, edge.Kind, edge.Valid})
+	}
+	if err := gob.NewEncoder(buffered).Encode(contents); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot. It returns ErrStaleSnapshot if the snapshot does not belong
+// to a graph of the ecosystem created from a source with the checksum.
+func ReadSnapshot(r io.Reader, ecosystem Ecosystem, checksum [32]byte) (*Snapshot, error) {
+	buffered := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(buffered, magic); err != nil || !bytes.Equal(magic, snapshotMagic) {
+		return nil, errors.New("not a graph snapshot")
+	}
+	var header snapshotHeader
+	if err := binary.Read(buffered, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if header.Version != SnapshotVersion || header.Checksum != checksum {
+		return nil, ErrStaleSnapshot
+	}
+	ecosystemName := make([]byte, header.EcosystemLength)
+	if _, err := io.ReadFull(buffered, ecosystemName); err != nil {
+		return nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if string(ecosystemName) != ecosystem.Name() {
+		return nil, ErrStaleSnapshot
+	}
+
+	var contents snapshotContents
+	if err := gob.NewDecoder(buffered).Decode(&contents); err != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if contents.Diagnostics == nil {
+		return nil, errors.New("reading snapshot: the diagnostics are missing")
+	}
+	contents.Diagnostics.unparsableVersions = make(map[string]bool, len(contents.UnparsableVersions))
+	for _, version := range contents.UnparsableVersions {
+		contents.Diagnostics.unparsableVersions[version] = true
+	}
+
+	snapshot := &Snapshot{
+		Graph:       simple.NewDirectedGraph(),
+		Nodes:       NewNodeIndex(len(contents.Nodes)),
+		NodeInfo:    make(map[int64]NodeInfo, len(contents.Nodes)),
+		Versions:    NewVersionIndex(len(contents.Versions)),
+		Diagnostics: contents.Diagnostics,
+	}
+	for _, node := range contents.Nodes {
+		info := NewNodeInfo(node.Id, node.Ecosystem, node.Name, node.Version, node.Timestamp, node.Metadata)
+		if err := snapshot.Nodes.Add(info.PackageVersion(), node.Id); err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+		snapshot.NodeInfo[node.Id] = *info
+		snapshot.Graph.AddNode(simple.Node(node.Id))
+	}
+	for _, pkg := range contents.Versions {
+		snapshot.Versions.Add(pkg.Name, pkg.Versions...)
+	}
+	for _, edge := range contents.Edges {
+		snapshot.Graph.SetEdge(GraphEdge{FId: edge.From, TId: edge.To, Kind: edge.Kind, Valid: edge.Valid, g: snapshot.Graph})
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot writes the snapshot to the file at path, replacing it only once the snapshot is complete.
+func SaveSnapshot(path string, snapshot *Snapshot, ecosystem Ecosystem, checksum [32]byte) error {
+	temporary := path + ".tmp"
+	f, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+	err = WriteSnapshot(f, snapshot, ecosystem, checksum)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("saving snapshot to %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads the snapshot in the file at path. Like ReadSnapshot, it returns ErrStaleSnapshot if the snapshot
+// is out of date, and it returns an error satisfying errors.Is(err, fs.ErrNotExist) if there is no snapshot yet.
+func LoadSnapshot(path string, ecosystem Ecosystem, checksum [32]byte) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snapshot, err := ReadSnapshot(f, ecosystem, checksum)
+	if err != nil {
+		return nil, fmt.Errorf("loading snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
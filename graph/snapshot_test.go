@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	graph, nodes, nodeInfo, versions, diagnostics, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var withMetadata int64
+	for id, info := range nodeInfo {
+		withMetadata = id
+		info.Metadata = &Metadata{License: "Apache-2.0", Repository: "https://github.com/example/example"}
+		nodeInfo[id] = info
+		break
+	}
+	reported := Issue{Kind: UnparsableVersion, Package: "org.example:broken", Version: "not a version"}
+	diagnostics.record(reported)
+	checksum := [32]byte{1, 2, 3}
+	var buffer bytes.Buffer
+	snapshot := &Snapshot{Graph: graph, Nodes: nodes, NodeInfo: nodeInfo, Versions: versions, Diagnostics: diagnostics}
+	if err := WriteSnapshot(&buffer, snapshot, Maven, checksum); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadSnapshot(bytes.NewReader(buffer.Bytes()), Maven, checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Graph.Nodes().Len() != graph.Nodes().Len() || len(loaded.NodeInfo) != len(nodeInfo) || loaded.Nodes.Len() != nodes.Len() {
+		t.Errorf("Expected %d nodes, got %d in the graph, %d infos and %d in the index", graph.Nodes().Len(),
+			loaded.Graph.Nodes().Len(), len(loaded.NodeInfo), loaded.Nodes.Len())
+	}
+	for id, info := range nodeInfo {
+		loadedInfo := loaded.NodeInfo[id]
+		if loadedInfo.PackageVersion() != info.PackageVersion() || loadedInfo.id != info.id ||
+			!loadedInfo.Timestamp.Equal(info.Timestamp) || (loadedInfo.Metadata == nil) != (info.Metadata == nil) {
+			t.Errorf("Expected node %d to be %+v, got %+v", id, info, loadedInfo)
+		}
+		if loadedId, ok := loaded.Nodes.Lookup(info.PackageVersion()); !ok || loadedId != id {
+			t.Errorf("Expected %s to be node %d, got %d, %v", info, id, loadedId, ok)
+		}
+	}
+	if metadata := loaded.NodeInfo[withMetadata].Metadata; metadata == nil || *metadata != *nodeInfo[withMetadata].Metadata {
+		t.Errorf("Expected the metadata of node %d to be kept, got %+v", withMetadata, metadata)
+	}
+	expectedEdges, loadedEdges := edgeSet(graph, nodeInfo), edgeSet(loaded.Graph, loaded.NodeInfo)
+	if len(expectedEdges) == 0 || len(loadedEdges) != len(expectedEdges) {
+		t.Errorf("Expected %d edges, got %d", len(expectedEdges), len(loadedEdges))
+	}
+	for edge := range expectedEdges {
+		if !loadedEdges[edge] {
+			t.Errorf("Missing edge %s", edge)
+		}
+	}
+	edges := graph.Edges()
+	for edges.Next() {
+		expected := edges.Edge().(GraphEdge)
+		if loadedEdge, ok := loaded.Graph.Edge(expected.FId, expected.TId).(GraphEdge); ok && (!loadedEdge.Valid.From.Equal(expected.Valid.From) ||
+			!loadedEdge.Valid.Until.Equal(expected.Valid.Until)) {
+			t.Errorf("Expected edge %d -> %d to be valid %+v, got %+v", expected.FId, expected.TId, expected.Valid, loadedEdge.Valid)
+		}
+	}
+	for _, pkg := range packagesInfo {
+		expected, _ := versions.Lookup(pkg.Name)
+		if loadedVersions, ok := loaded.Versions.Lookup(pkg.Name); !ok || len(loadedVersions) != len(expected) {
+			t.Errorf("Expected the versions %v of %s, got %v", expected, pkg.Name, loadedVersions)
+		}
+	}
+	if loaded.Diagnostics.Dependencies != diagnostics.Dependencies || loaded.Diagnostics.Cache != diagnostics.Cache {
+		t.Errorf("Expected diagnostics %+v, got %+v", diagnostics, loaded.Diagnostics)
+	}
+	for _, kind := range IssueKinds {
+		if loaded.Diagnostics.Count(kind) != diagnostics.Count(kind) {
+			t.Errorf("Expected %d issues of kind %s, got %d", diagnostics.Count(kind), kind, loaded.Diagnostics.Count(kind))
+		}
+	}
+	unparsable := loaded.Diagnostics.Count(UnparsableVersion)
+	loaded.Diagnostics.record(reported)
+	if loaded.Diagnostics.Count(UnparsableVersion) != unparsable {
+		t.Errorf("Expected %s %s, which was reported before saving, not to be reported again", reported.Package, reported.Version)
+	}
+	loaded.Diagnostics.record(Issue{Kind: UnparsableVersion, Package: "org.example:broken", Version: "neither a version"})
+	loaded.Diagnostics.record(Issue{Kind: UnparsableConstraint, Package: "org.example:app", Version: "1.0", Dependency: "org.example:lib", Constraint: "[1.0"})
+	if loaded.Diagnostics.Count(UnparsableVersion) != unparsable+1 || loaded.Diagnostics.Count(UnparsableConstraint) != diagnostics.Count(UnparsableConstraint)+1 {
+		t.Errorf("Expected the loaded diagnostics to record new issues, got %+v", loaded.Diagnostics.Issues)
+	}
+
+	t.Run("ConflictingNodes", func(t *testing.T) {
+		conflicting := make(map[int64]NodeInfo, len(nodeInfo)+1)
+		for id, info := range nodeInfo {
+			conflicting[id] = info
+		}
+		duplicate := nodeInfo[withMetadata]
+		duplicate.id = graph.NewNode().ID() // Another node for the same package version
+		conflicting[duplicate.id] = duplicate
+		var conflictingBuffer bytes.Buffer
+		conflictingSnapshot := &Snapshot{Graph: graph, Nodes: nodes, NodeInfo: conflicting, Versions: versions, Diagnostics: diagnostics}
+		if err := WriteSnapshot(&conflictingBuffer, conflictingSnapshot, Maven, checksum); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadSnapshot(&conflictingBuffer, Maven, checksum); err == nil {
+			t.Errorf("Expected an error for a snapshot with two nodes for %s", duplicate)
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		if _, err := ReadSnapshot(bytes.NewReader(buffer.Bytes()), Maven, [32]byte{4, 5, 6}); !errors.Is(err, ErrStaleSnapshot) {
+			t.Errorf("Expected a snapshot of another source to be stale, got %v", err)
+		}
+		if _, err := ReadSnapshot(bytes.NewReader(buffer.Bytes()), NPM, checksum); !errors.Is(err, ErrStaleSnapshot) {
+			t.Errorf("Expected a snapshot of another ecosystem to be stale, got %v", err)
+		}
+		outdated := append([]byte(nil), buffer.Bytes()...)
+		outdated[len(snapshotMagic)]++ // The version follows the magic bytes
+		if _, err := ReadSnapshot(bytes.NewReader(outdated), Maven, checksum); !errors.Is(err, ErrStaleSnapshot) {
+			t.Errorf("Expected a snapshot of another version to be stale, got %v", err)
+		}
+		if _, err := ReadSnapshot(bytes.NewReader([]byte("not a snapshot")), Maven, checksum); err == nil || errors.Is(err, ErrStaleSnapshot) {
+			t.Errorf("Expected an error for a file that is not a snapshot, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// Validity is the interval [From, Until) in which an edge is current: both of its package versions have been
+// published, and no higher version that matches the same constraint has been published yet. From is the later of the
+// two timestamps, and Until is when the first higher matching version was published, or zero if there is none, so the
+// edge stays current. An edge whose higher matching version was published before From was never current, and neither
+// was an edge between package versions of which a timestamp is unknown, which has the zero Validity.
+type Validity struct {
+	From, Until time.Time
+}
+
+// newValidity returns the validity of an edge from a package version published at from to one published at to, which
+// was superseded at supersededAt.
+func newValidity(from, to, supersededAt time.Time) Validity {
+	if from.IsZero() || to.IsZero() {
+		return Validity{}
+	}
+	if to.After(from) {
+		from = to
+	}
+	return Validity{From: from, Until: supersededAt}
+}
+
+// Empty reports whether the edge was never current.
+func (v Validity) Empty() bool {
+	return v.From.IsZero() || !v.Until.IsZero() && !v.Until.After(v.From)
+}
+
+// Contains reports whether the edge was current at t.
+func (v Validity) Contains(t time.Time) bool {
+	return v.Overlaps(t, t)
+}
+
+// Overlaps reports whether the edge was current at some moment of the interval [begin, end].
+func (v Validity) Overlaps(begin, end time.Time) bool {
+	return !v.Empty() && !v.From.After(end) && (v.Until.IsZero() || v.Until.After(begin))
+}
+
+// edgeValidity returns the validity of a GraphEdge or CSREdge, and false for other edges, which have none.
+func edgeValidity(e graph.Edge) (Validity, bool) {
+	switch edge := e.(type) {
+	case GraphEdge:
+		return edge.Valid, true
+	case CSREdge:
+		return edge.Valid, true
+	default:
+		return Validity{}, false
+	}
+}
@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEdgeValidity(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	graph, _, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := func(purl string) time.Time {
+		key, _ := ParsePackageVersion(purl)
+		for _, info := range nodeMap {
+			if info.PackageVersion() == key {
+				return info.Timestamp
+			}
+		}
+		t.Fatalf("Package version %s not found", purl)
+		return time.Time{}
+	}
+	b, c := published("pkg:maven/B@1.0.0"), published("pkg:maven/C@1.0.0")
+
+	expected := map[string]Validity{
+		// 2.0.1 is the highest version that matches, so B resolves to it for good once both are published
+		"pkg:maven/B@1.0.0 -> pkg:maven/A@2.0.1": {From: b},
+		// 2.0.1 was published a month before 1.1.0, so 1.1.0 was superseded before it was published
+		"pkg:maven/B@1.0.0 -> pkg:maven/A@1.1.0": {From: published("pkg:maven/A@1.1.0"), Until: published("pkg:maven/A@2.0.1")},
+		"pkg:maven/B@1.0.0 -> pkg:maven/C@1.0.0": {From: b},
+		// 1.0.0 was published at the same moment as C, so 0.9.0 was never current
+		"pkg:maven/C@1.0.0 -> pkg:maven/A@0.9.0": {From: c, Until: published("pkg:maven/A@1.0.0")},
+		"pkg:maven/C@1.0.0 -> pkg:maven/A@1.0.0": {From: c},
+	}
+	edges := graph.Edges()
+	for edges.Next() {
+		edge := edges.Edge().(GraphEdge)
+		name := nodeMap[edge.FId].String() + " -> " + nodeMap[edge.TId].String()
+		valid, ok := expected[name]
+		if !ok {
+			t.Errorf("Unexpected edge %s", name)
+		} else if !edge.Valid.From.Equal(valid.From) || !edge.Valid.Until.Equal(valid.Until) {
+			t.Errorf("Expected edge %s to be valid %+v, got %+v", name, valid, edge.Valid)
+		}
+	}
+}
+
+func TestValidityOverlaps(t *testing.T) {
+	date := func(month time.Month) time.Time {
+		return time.Date(2021, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name       string
+		valid      Validity
+		begin, end time.Time
+		expected   bool
+	}{
+		{"Within", Validity{From: date(2), Until: date(5)}, date(3), date(4), true},
+		{"Ends at the beginning", Validity{From: date(2), Until: date(3)}, date(3), date(4), false},
+		{"Begins at the end", Validity{From: date(4), Until: date(5)}, date(3), date(4), true},
+		{"After", Validity{From: date(5)}, date(3), date(4), false},
+		{"Still current", Validity{From: date(1)}, date(3), date(4), true},
+		{"Never current", Validity{From: date(2), Until: date(1)}, date(1), date(12), false},
+		{"Unknown timestamps", Validity{}, date(1), date(12), false},
+	}
+	for _, c := range cases {
+		if overlaps := c.valid.Overlaps(c.begin, c.end); overlaps != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, overlaps)
+		}
+	}
+	if !(Validity{From: date(2), Until: date(3)}).Contains(date(2)) || (Validity{From: date(2), Until: date(3)}).Contains(date(3)) {
+		t.Error("Expected the validity to include its start and exclude its end")
+	}
+}
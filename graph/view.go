@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+)
+
+// View is a read-only subgraph of another directed graph, selected by predicates on its nodes and edges. It implements
+// graph.Directed without copying or changing the graph it is backed by, so any number of views of the same graph can
+// be queried one after the other, and views can be stacked on top of each other.
+type View struct {
+	g        graph.Directed
+	keepNode func(id int64) bool
+	keepEdge func(fromId, toId int64) bool
+}
+
+// NewView creates a view of g with the nodes for which keepNode returns true, and the edges between them for which
+// keepEdge returns true. A nil predicate keeps everything.
+func NewView(g graph.Directed, keepNode func(id int64) bool, keepEdge func(fromId, toId int64) bool) *View {
+	return &View{g: g, keepNode: keepNode, keepEdge: keepEdge}
+}
+
+// WindowView returns a view of g with the package versions published within the interval [beginTime, endTime]. It
+// selects the same nodes as FilterNoTraversal, without removing the others from g.
+func WindowView(g graph.Directed, nodeMap map[int64]NodeInfo, beginTime, endTime time.Time) *View {
+	return NewView(g, func(id int64) bool {
+		return InInterval(nodeMap[id].Timestamp, beginTime, endTime)
+	}, nil)
+}
+
+// LatestView returns a view of g with only the latest version of every package in it. It selects the same nodes as
+// LatestNoTraversal, without removing the others from g. Since the latest versions are found when the view is created,
+// g, which can be a view itself, should not change afterwards. Versions published at the same time are ordered by the
+// ecosystem.
+func LatestView(g graph.Directed, nodeMap map[int64]NodeInfo, ecosystem Ecosystem) *View {
+	latest := latestVersions(g.Nodes(), nodeMap, ecosystem)
+	return NewView(g, func(id int64) bool {
+		_, ok := latest[id]
+		return ok
+	}, nil)
+}
+
+// ValidView returns a view of g with the edges that were current at some moment of the interval [beginTime, endTime],
+// according to their Validity. With a single moment as the interval, every dependency keeps only the edge to the
+// version it resolved to at that moment, so the latest dependencies of a package version at a moment are the ones
+// reachable from it. The validity is read from GraphEdges and CSREdges; other edges have none and are kept. Stack it on
+// top of a WindowView to also leave out the package versions that were not published within the interval.
+func ValidView(g graph.Directed, beginTime, endTime time.Time) *View {
+	return NewView(g, nil, func(fromId, toId int64) bool {
+		valid, ok := edgeValidity(g.Edge(fromId, toId))
+		return !ok || valid.Overlaps(beginTime, endTime)
+	})
+}
+
+func (v *View) hasNode(id int64) bool {
+	return (v.keepNode == nil || v.keepNode(id)) && v.g.Node(id) != nil
+}
+
+func (v *View) Node(id int64) graph.Node {
+	if v.keepNode != nil && !v.keepNode(id) {
+		return nil
+	}
+	return v.g.Node(id)
+}
+
+func (v *View) Nodes() graph.Nodes {
+	return v.filter(v.g.Nodes(), func(n graph.Node) bool {
+		return v.keepNode == nil || v.keepNode(n.ID())
+	})
+}
+
+func (v *View) From(id int64) graph.Nodes {
+	if !v.hasNode(id) {
+		return graph.Empty
+	}
+	return v.filter(v.g.From(id), func(n graph.Node) bool {
+		return (v.keepNode == nil || v.keepNode(n.ID())) && (v.keepEdge == nil || v.keepEdge(id, n.ID()))
+	})
+}
+
+func (v *View) To(id int64) graph.Nodes {
+	if !v.hasNode(id) {
+		return graph.Empty
+	}
+	return v.filter(v.g.To(id), func(n graph.Node) bool {
+		return (v.keepNode == nil || v.keepNode(n.ID())) && (v.keepEdge == nil || v.keepEdge(n.ID(), id))
+	})
+}
+
+func (v *View) HasEdgeFromTo(uid, vid int64) bool {
+	if v.keepNode != nil && (!v.keepNode(uid) || !v.keepNode(vid)) {
+		return false
+	}
+	return v.g.HasEdgeFromTo(uid, vid) && (v.keepEdge == nil || v.keepEdge(uid, vid))
+}
+
+func (v *View) HasEdgeBetween(xid, yid int64) bool {
+	return v.HasEdgeFromTo(xid, yid) || v.HasEdgeFromTo(yid, xid)
+}
+
+func (v *View) Edge(uid, vid int64) graph.Edge {
+	if !v.HasEdgeFromTo(uid, vid) {
+		return nil
+	}
+	return v.g.Edge(uid, vid)
+}
+
+// filter returns the nodes for which keep returns true, without going over them before they are asked for.
+func (v *View) filter(nodes graph.Nodes, keep func(n graph.Node) bool) graph.Nodes {
+	return &filteredNodes{nodes: nodes, keep: keep}
+}
+
+// filteredNodes iterates over the nodes of another iterator for which keep returns true, skipping the others in Next.
+// Only Len, which the algorithms of gonum call to know how many nodes an iterator has, goes over the remaining nodes
+// up front, and keeps the ones it counted for Next.
+type filteredNodes struct {
+	nodes   graph.Nodes
+	keep    func(n graph.Node) bool
+	current graph.Node
+
+	counted   bool         // Whether Len has collected the remaining nodes
+	remaining []graph.Node // The remaining nodes, once Len has collected them
+}
+
+func (it *filteredNodes) Next() bool {
+	if it.counted {
+		if len(it.remaining) == 0 {
+			it.current = nil
+			return false
+		}
+		it.current, it.remaining = it.remaining[0], it.remaining[1:]
+		return true
+	}
+	for it.nodes.Next() {
+		if n := it.nodes.Node(); it.keep(n) {
+			it.current = n
+			return true
+		}
+	}
+	it.current = nil
+	return false
+}
+
+func (it *filteredNodes) Len() int {
+	if !it.counted {
+		for it.nodes.Next() {
+			if n := it.nodes.Node(); it.keep(n) {
+				it.remaining = append(it.remaining, n)
+			}
+		}
+		it.counted = true
+	}
+	return len(it.remaining)
+}
+
+func (it *filteredNodes) Reset() {
+	it.nodes.Reset()
+	it.current, it.counted, it.remaining = nil, false, nil
+}
+
+func (it *filteredNodes) Node() graph.Node {
+	return it.current
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// viewEdges returns the edges of a graph as "from -> to" purls, found through From and checked against To and Edge.
+func viewEdges(t *testing.T, g graph.Directed, nodeMap map[int64]NodeInfo) map[string]bool {
+	edges := make(map[string]bool)
+	nodes := g.Nodes()
+	for nodes.Next() {
+		from := nodes.Node().ID()
+		targets := g.From(from)
+		for targets.Next() {
+			to := targets.Node().ID()
+			if g.Edge(from, to) == nil || !g.HasEdgeFromTo(from, to) || !nodeIDs(g.To(to))[from] {
+				t.Errorf("Edge from %s to %s is not reported consistently", nodeMap[from], nodeMap[to])
+			}
+			edges[nodeMap[from].String()+" -> "+nodeMap[to].String()] = true
+		}
+	}
+	return edges
+}
+
+func TestViewsLeaveTheGraphUnchanged(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	source, nodes, nodeMap, _, diagnostics, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name     string
+		view     *View
+		expected []string
+	}{
+		{"2021 until the 22nd of April", WindowView(source, nodeMap, date(2021, 1, 1), date(2021, 4, 23)), []string{
+			"pkg:maven/B@1.0.0 -> pkg:maven/A@2.0.1",
+			"pkg:maven/B@1.0.0 -> pkg:maven/C@1.0.0",
+			"pkg:maven/C@1.0.0 -> pkg:maven/A@1.0.0",
+		}},
+		{"2020", WindowView(source, nodeMap, date(2020, 1, 1), date(2021, 1, 1)), nil},
+		{"Latest versions", LatestView(source, nodeMap, Maven), []string{
+			"pkg:maven/B@1.0.0 -> pkg:maven/A@1.1.0",
+			"pkg:maven/B@1.0.0 -> pkg:maven/C@1.0.0",
+		}},
+		{"Current on the 1st of May 2021", ValidView(source, date(2021, 5, 1), date(2021, 5, 1)), []string{
+			"pkg:maven/B@1.0.0 -> pkg:maven/A@2.0.1",
+			"pkg:maven/B@1.0.0 -> pkg:maven/C@1.0.0",
+			"pkg:maven/C@1.0.0 -> pkg:maven/A@1.0.0",
+		}},
+		{"Current in 2020", ValidView(source, date(2020, 1, 1), date(2021, 1, 1)), nil},
+		{"Latest versions of 2021 until the 22nd of April", LatestView(WindowView(source, nodeMap, date(2021, 1, 1), date(2021, 4, 23)), nodeMap, Maven), []string{
+			"pkg:maven/B@1.0.0 -> pkg:maven/C@1.0.0",
+			"pkg:maven/C@1.0.0 -> pkg:maven/A@1.0.0",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			edges := viewEdges(t, c.view, nodeMap)
+			if len(edges) != len(c.expected) {
+				t.Errorf("Expected the edges %v, got %v", c.expected, edges)
+			}
+			for _, edge := range c.expected {
+				if !edges[edge] {
+					t.Errorf("Expected the edge %s, got %v", edge, edges)
+				}
+			}
+			if source.Nodes().Len() != len(nodeMap) || source.Edges().Len() != diagnostics.Edges {
+				t.Errorf("Expected the graph to keep its %d nodes and %d edges, got %d and %d", len(nodeMap),
+					diagnostics.Edges, source.Nodes().Len(), source.Edges().Len())
+			}
+		})
+	}
+
+	t.Run("Nodes outside of the view", func(t *testing.T) {
+		window := WindowView(source, nodeMap, date(2020, 1, 1), date(2021, 1, 1))
+		outside := nodeId(t, nodes, "pkg:maven/B@1.0.0")
+		if window.Node(outside) != nil || window.From(outside).Len() != 0 || window.To(outside).Len() != 0 {
+			t.Error("Expected B to be left out of the view")
+		}
+		if dependencies := GetTransitiveDependenciesNode(window, nodeMap, nodes, nodeMap[outside].PackageVersion()); len(*dependencies) != 0 {
+			t.Errorf("Expected no dependencies of a package version outside of the view, got %v", *dependencies)
+		}
+		if dependencies := GetTransitiveDependenciesNode(source, nodeMap, nodes, nodeMap[outside].PackageVersion()); len(*dependencies) != 6 {
+			t.Errorf("Expected B and its 5 dependencies in the graph, got %v", *dependencies)
+		}
+	})
+}
+
+func TestLatestVersionsPublishedTogether(t *testing.T) {
+	packagesInfo := []PackageInfo{
+		{
+			Name: "app",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Timestamp: "2021-01-01T00:00:00Z", Dependencies: map[string]string{"lib": "^1.0.0"}},
+			},
+		},
+		{
+			Name: "lib",
+			Versions: map[string]VersionInfo{
+				"1.9.0":  {Timestamp: "2021-01-01T00:00:00Z"},
+				"1.10.0": {Timestamp: "2021-01-01T00:00:00Z"},
+			},
+		},
+	}
+	source, nodes, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, NPM, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := LatestView(source, nodeMap, NPM)
+	if latest.Node(nodeId(t, nodes, "pkg:npm/lib@1.10.0")) == nil || latest.Node(nodeId(t, nodes, "pkg:npm/lib@1.9.0")) != nil {
+		t.Errorf("Expected lib 1.10.0 to be the latest version, got %v", viewEdges(t, latest, nodeMap))
+	}
+
+	dependencies := GetLatestTransitiveDependenciesNode(source, nodeMap, nodes, NPM, PackageVersion{Ecosystem: NPM.Name(), Name: "app", Version: "1.0.0"})
+	if len(*dependencies) != 2 || (*dependencies)[1].Version != "1.10.0" {
+		t.Errorf("Expected app 1.0.0 to depend on lib 1.10.0, got %v", *dependencies)
+	}
+}
+
+func TestLatestDependenciesInWindow(t *testing.T) {
+	packagesInfo := ParseJSON("../data/input/test_data.json")
+	source, nodes, nodeMap, _, _, err := CreateGraph(&sliceSource{packages: packagesInfo}, Maven, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	begin := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A 1.1.0 was published after B, but A 2.0.1 already matched the constraint of B, so the edge to it never was
+	// current and A 1.0.0, which C depends on, is the latest version of A
+	window := ValidView(WindowView(source, nodeMap, begin, end), begin, end)
+	dependencies := GetLatestTransitiveDependenciesNode(window, nodeMap, nodes, Maven, PackageVersion{Ecosystem: "maven", Name: "B", Version: "1.0.0"})
+	found := make(map[string]bool)
+	for _, dependency := range *dependencies {
+		found[dependency.String()] = true
+	}
+	expected := map[string]bool{"pkg:maven/B@1.0.0": true, "pkg:maven/C@1.0.0": true, "pkg:maven/A@1.0.0": true}
+	if len(*dependencies) != len(expected) || !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected the latest dependencies %v, got %v", expected, *dependencies)
+	}
+}
+
+func TestViewIteratesLazily(t *testing.T) {
+	source := simple.NewDirectedGraph()
+	for id := int64(0); id < 6; id++ {
+		source.AddNode(simple.Node(id))
+	}
+	checked := 0
+	view := NewView(source, func(id int64) bool {
+		checked++
+		return id%2 == 0
+	}, nil)
+
+	nodes := view.Nodes()
+	if checked != 0 {
+		t.Errorf("Expected no node to be checked before the first one is asked for, %d were", checked)
+	}
+	if !nodes.Next() || nodes.Node().ID()%2 != 0 {
+		t.Fatalf("Expected a node with an even ID, got %v", nodes.Node())
+	}
+	if nodes.Len() != 2 {
+		t.Errorf("Expected 2 remaining nodes, got %d", nodes.Len())
+	}
+	seen := map[int64]bool{nodes.Node().ID(): true}
+	for nodes.Next() {
+		seen[nodes.Node().ID()] = true
+	}
+	if !sameNodes(seen, map[int64]bool{0: true, 2: true, 4: true}) || nodes.Node() != nil || nodes.Len() != 0 {
+		t.Errorf("Expected nodes 0, 2 and 4, got %v", seen)
+	}
+	nodes.Reset()
+	if nodes.Len() != 3 || !sameNodes(nodeIDs(nodes), seen) {
+		t.Errorf("Expected the reset iterator to return nodes 0, 2 and 4 again")
+	}
+}
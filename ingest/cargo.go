@@ -0,0 +1,215 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func init() {
+	Register(Format{
+		Name:      "cargo",
+		Dir:       isCratesIndex,
+		OpenDir:   NewCargoReader,
+		Ecosystem: graph.Cargo,
+	})
+}
+
+// cratesIndexConfig is the file at the root of every registry index that tells Cargo where to download crates.
+const cratesIndexConfig = "config.json"
+
+// isCratesIndex reports whether the directory at path is a checkout of a Cargo registry index such as crates.io.
+func isCratesIndex(path string) bool {
+	data, err := os.ReadFile(filepath.Join(path, cratesIndexConfig))
+	if err != nil {
+		return false
+	}
+	var config struct {
+		Download string `json:"dl"`
+	}
+	return json.Unmarshal(data, &config) == nil && config.Download != ""
+}
+
+type cargoDependency struct {
+	Name     string `json:"name"`
+	Req      string `json:"req"`
+	Kind     string `json:"kind"`
+	Optional bool   `json:"optional"` // Only used when a feature of the crate enables it
+	Registry string `json:"registry"`
+	Package  string `json:"package"` // The real name of a renamed dependency
+}
+
+// cargoKindPrecedence decides which kind a crate that is depended on several times is recorded with.
+var cargoKindPrecedence = map[string]int{"": 0, "normal": 0, "optional": 1, "build": 2, "dev": 3}
+
+type cargoVersion struct {
+	Name    string            `json:"name"`
+	Version string            `json:"vers"`
+	Deps    []cargoDependency `json:"deps"`
+	PubTime string            `json:"pubtime"`
+}
+
+// CargoReader reads a checkout of a Cargo registry index, such as https://github.com/rust-lang/crates.io-index.
+// Every file of the index is one crate and holds one JSON line per published version. Yanked versions are kept,
+// since they were available until they were yanked. Dependencies on crates of other registries are left out, renamed
+// dependencies are recorded under the name of the crate they refer to, and the kind of dev and build dependencies is
+// kept in the Kinds of the version. Regular dependencies that only a feature enables get the kind "optional". The timestamp of a version is its pubtime, or the modification time of the file
+// for entries that were published before the index recorded it.
+type CargoReader struct {
+	root  string
+	files []string
+	read  bool
+
+	Malformed []RowError
+}
+
+// NewCargoReader creates a CargoReader for the index at root. Crates are read one file at a time.
+func NewCargoReader(root string) (Source, error) {
+	return &CargoReader{root: root}, nil
+}
+
+func (c *CargoReader) Next() (graph.PackageInfo, error) {
+	if !c.read {
+		if err := c.listCrates(); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		c.read = true
+	}
+	for len(c.files) > 0 {
+		path := c.files[0]
+		c.files = c.files[1:]
+		pkg, err := c.readCrate(path)
+		if err != nil {
+			c.skip(path, 0, err)
+			continue
+		}
+		if len(pkg.Versions) > 0 {
+			return pkg, nil
+		}
+	}
+	return graph.PackageInfo{}, io.EOF
+}
+
+func (c *CargoReader) Skipped() []RowError {
+	return c.Malformed
+}
+
+// Close does nothing, since every file of the directory is closed as soon as it has been read.
+func (c *CargoReader) Close() error {
+	return nil
+}
+
+func (c *CargoReader) skip(path string, line int, err error) {
+	if rel, relErr := filepath.Rel(c.root, path); relErr == nil {
+		path = rel
+	}
+	c.Malformed = append(c.Malformed, RowError{File: path, Line: line, Err: err})
+}
+
+// listCrates finds the files of the index, leaving out its configuration and hidden directories such as .git.
+func (c *CargoReader) listCrates() error {
+	return filepath.WalkDir(c.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != c.root {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || path == filepath.Join(c.root, cratesIndexConfig) {
+			return nil
+		}
+		c.files = append(c.files, path)
+		return nil
+	})
+}
+
+// readCrate reads the versions of the crate in the index file at path.
+func (c *CargoReader) readCrate(path string) (graph.PackageInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return graph.PackageInfo{}, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return graph.PackageInfo{}, err
+	}
+	modified := stat.ModTime().UTC().Format(time.RFC3339)
+
+	pkg := graph.PackageInfo{Versions: make(map[string]graph.VersionInfo)}
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(data))) > 0 {
+			var entry cargoVersion
+			if jsonErr := json.Unmarshal(data, &entry); jsonErr != nil {
+				c.skip(path, line, jsonErr)
+			} else if entry.Name == "" || entry.Version == "" {
+				c.skip(path, line, errors.New("entry has no name or version"))
+			} else if pkg.Name != "" && entry.Name != pkg.Name {
+				c.skip(path, line, fmt.Errorf("entry of %s in the file of %s", entry.Name, pkg.Name))
+			} else {
+				pkg.Name = entry.Name
+				pkg.Versions[entry.Version] = entry.versionInfo(modified)
+			}
+		}
+		if err == io.EOF {
+			return pkg, nil
+		}
+		if err != nil {
+			return graph.PackageInfo{}, err
+		}
+	}
+}
+
+func (entry cargoVersion) versionInfo(modified string) graph.VersionInfo {
+	info := graph.VersionInfo{
+		Dependencies: make(map[string]string, len(entry.Deps)),
+		Timestamp:    modified,
+	}
+	if t, err := time.Parse(time.RFC3339, entry.PubTime); err == nil {
+		info.Timestamp = t.UTC().Format(time.RFC3339)
+	}
+	kinds := make(map[string]string)
+	for _, dependency := range entry.Deps {
+		if dependency.Registry != "" {
+			continue
+		}
+		name := dependency.Name
+		if dependency.Package != "" {
+			name = dependency.Package
+		}
+		kind := dependency.Kind
+		if kind == "normal" {
+			kind = ""
+		}
+		if kind == "" && dependency.Optional {
+			kind = "optional"
+		}
+		if _, ok := info.Dependencies[name]; ok && cargoKindPrecedence[kinds[name]] <= cargoKindPrecedence[kind] {
+			continue
+		}
+		info.Dependencies[name] = dependency.Req
+		if kind == "" {
+			delete(kinds, name)
+		} else {
+			kinds[name] = kind
+		}
+	}
+	if len(kinds) > 0 {
+		info.Kinds = kinds
+	}
+	return info
+}
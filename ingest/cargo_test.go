@@ -0,0 +1,67 @@
+package ingest
+
+import (
+	"io"
+	"testing"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestCargoReader(t *testing.T) {
+	if format, err := Detect("testdata/crates"); err != nil || format.Name != "cargo" {
+		t.Fatalf("Expected the fixture to be detected as a crates index, got %v (%v)", format.Name, err)
+	}
+	source, err := Open("testdata/crates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	packages := make(map[string]map[string]bool)
+	crates := make(map[string]graph.PackageInfo)
+	for {
+		pkg, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		crates[pkg.Name] = pkg
+		packages[pkg.Name] = make(map[string]bool)
+		for version := range pkg.Versions {
+			packages[pkg.Name][version] = true
+		}
+	}
+
+	t.Run("Reads every crate and skips malformed lines", func(t *testing.T) {
+		if len(packages) != 3 || len(packages["log"]) != 2 || len(packages["serde"]) != 2 {
+			t.Errorf("Unexpected packages %v", packages)
+		}
+		skipped := source.Skipped()
+		if len(skipped) != 1 || skipped[0].Line != 3 {
+			t.Errorf("Expected the third line of log to be skipped, got %v", skipped)
+		}
+	})
+
+	serde := crates["serde"].Versions["1.0.100"]
+	t.Run("Keeps dependency kinds", func(t *testing.T) {
+		if serde.Dependencies["cc"] != "^1.0.3" || serde.Kinds["cc"] != "" {
+			t.Errorf("Expected the regular dependency on cc to take precedence, got %v %v", serde.Dependencies, serde.Kinds)
+		}
+		if serde.Dependencies["serde_derive"] != "^0.2" || serde.Kinds["serde_derive"] != "dev" {
+			t.Errorf("Expected the renamed dev dependency on serde_derive, got %v %v", serde.Dependencies, serde.Kinds)
+		}
+		if serde.Dependencies["log"] != "^0.4" || serde.Kinds["log"] != "optional" {
+			t.Errorf("Expected the optional dependency on log to be marked, got %v %v", serde.Dependencies, serde.Kinds)
+		}
+		if _, ok := serde.Dependencies["other"]; ok {
+			t.Error("Expected the dependency of another registry to be left out")
+		}
+	})
+
+	t.Run("Uses pubtime for the timestamps", func(t *testing.T) {
+		if serde.Timestamp != "2019-09-01T00:00:00Z" {
+			t.Errorf("Unexpected timestamp %s", serde.Timestamp)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package ingest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checksum returns the SHA-256 checksum of the file at path, as it is stored, so compressed files are not
+// decompressed. For a directory, the checksum covers the path and content of every file in it, leaving out hidden
+// files and directories such as .git, which the readers of directories ignore as well.
+func Checksum(path string) ([32]byte, error) {
+	var sum [32]byte
+	hash := sha256.New()
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && file != path {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relative, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		// Every file is preceded by its name and size, so that moving bytes between files changes the checksum
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(relative), info.Size())
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(hash, f)
+		return err
+	})
+	if err != nil {
+		return sum, err
+	}
+	copy(sum[:], hash.Sum(nil))
+	return sum, nil
+}
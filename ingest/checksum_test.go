@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "packages.json")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checksum := func(path string) [32]byte {
+		t.Helper()
+		sum, err := Checksum(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+
+	write(file, `{"pkgs":[]}`)
+	original := checksum(file)
+	if checksum(file) != original {
+		t.Error("Expected the checksum of an unchanged file to stay the same")
+	}
+	write(file, `{"pkgs":[ ]}`)
+	if checksum(file) == original {
+		t.Error("Expected the checksum to change with the file")
+	}
+
+	index := filepath.Join(dir, "index")
+	write(filepath.Join(index, "ab", "c"), "a")
+	write(filepath.Join(index, "ab", "d"), "b")
+	original = checksum(index)
+	write(filepath.Join(index, ".git", "HEAD"), "ref: refs/heads/main")
+	if checksum(index) != original {
+		t.Error("Expected hidden directories to be left out of the checksum")
+	}
+	write(filepath.Join(index, "ab", "c"), "")
+	write(filepath.Join(index, "ab", "d"), "ab")
+	if checksum(index) == original {
+		t.Error("Expected the checksum to change when content moves between files")
+	}
+	if _, err := Checksum(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compression describes a compression format that is removed transparently before the content of a file is read.
+type compression struct {
+	name      string
+	extension string
+	magic     []byte
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var compressions = []compression{
+	{
+		name:      "gzip",
+		extension: ".gz",
+		magic:     []byte{0x1f, 0x8b},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:      "zstd",
+		extension: ".zst",
+		magic:     []byte{0x28, 0xb5, 0x2f, 0xfd},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			decoder, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return decoder.IOReadCloser(), nil
+		},
+	},
+}
+
+// input is an opened, decompressed input file.
+type input struct {
+	reader  *bufio.Reader
+	header  []byte // The first bytes of the decompressed content
+	name    string // The file name without the extension of the compression
+	closers []io.Closer
+}
+
+func (in *input) Close() error {
+	var err error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		if closeErr := in.closers[i].Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// peek wraps r in a buffered reader and returns the first bytes of it without consuming them.
+func peek(r io.Reader) (*bufio.Reader, []byte, error) {
+	reader := bufio.NewReaderSize(r, sniffLength)
+	header, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, nil, err
+	}
+	return reader, header, nil
+}
+
+// openInput opens the file at path. If the content is compressed, it is decompressed and the extension of the
+// compression is removed from the name, so that the format can be detected from what remains.
+func openInput(path string) (*input, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	in := &input{name: path, closers: []io.Closer{f}}
+
+	reader, header, err := peek(f)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	for _, c := range compressions {
+		if !bytes.HasPrefix(header, c.magic) {
+			continue
+		}
+		decompressed, err := c.newReader(reader)
+		if err != nil {
+			in.Close()
+			return nil, err
+		}
+		in.closers = append(in.closers, decompressed)
+		if reader, header, err = peek(decompressed); err != nil {
+			in.Close()
+			return nil, err
+		}
+		in.name = strings.TrimSuffix(path, c.extension)
+		break
+	}
+	in.reader = reader
+	in.header = header
+	return in, nil
+}
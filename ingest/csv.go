@@ -0,0 +1,301 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// The columns of the row-per-edge CSV format. Every row describes one dependency of one package version, so a
+// package version with n dependencies spans n rows. A row with an empty dependency column only declares the version.
+const (
+	csvName              = "name"
+	csvVersion           = "version"
+	csvUploadTime        = "upload_time"
+	csvDependency        = "dependency"
+	csvDependencyVersion = "dependency_version"
+
+	// Optional columns with the metadata of the version
+	csvAuthor      = "author"
+	csvLicense     = "license"
+	csvRepository  = "repository"
+	csvDescription = "description"
+)
+
+var csvRequiredColumns = []string{csvName, csvVersion, csvUploadTime, csvDependency, csvDependencyVersion}
+
+func init() {
+	Register(Format{
+		Name:       "csv",
+		Extensions: []string{".csv"},
+		Sniff:      sniffCSV,
+		New: func(r io.Reader) (Source, error) {
+			return NewCSVReader(r), nil
+		},
+	})
+}
+
+// sniffCSV recognises the header of the row-per-edge format.
+func sniffCSV(header []byte) bool {
+	firstLine := string(header)
+	if end := strings.IndexByte(firstLine, '\n'); end >= 0 {
+		firstLine = firstLine[:end]
+	}
+	firstLine = strings.ToLower(firstLine)
+	for _, column := range csvRequiredColumns {
+		if !strings.Contains(firstLine, column) {
+			return false
+		}
+	}
+	return true
+}
+
+// CSVReader streams the row-per-edge CSV format used by data/input/dependencies.csv and groups the rows into
+// PackageInfo structures. Malformed rows are skipped and recorded in Malformed together with their line number.
+type CSVReader struct {
+	r       *csv.Reader
+	columns map[string]int
+
+	current   *graph.PackageInfo // The package whose rows Next is reading
+	returned  map[string]bool    // The packages Next has returned
+	late      map[string]*graph.PackageInfo
+	lateOrder []string // Names of the packages in late, in the order their rows were found
+	done      bool
+
+	Malformed []RowError
+}
+
+// csvRow is a single well-formed row of the format.
+type csvRow struct {
+	name, version, timestamp, dependency, constraint string
+	record                                           []string
+}
+
+// NewCSVReader creates a CSVReader reading from r. The first record of r has to be the header.
+func NewCSVReader(r io.Reader) *CSVReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // We check the amount of fields ourselves so we can skip the row instead of failing
+	reader.ReuseRecord = true
+	return &CSVReader{
+		r:        reader,
+		returned: make(map[string]bool),
+		late:     make(map[string]*graph.PackageInfo),
+	}
+}
+
+// ParseCSV reads the CSV file at inPath and returns the packages it contains together with the rows that had to be
+// skipped.
+func ParseCSV(inPath string) ([]graph.PackageInfo, []RowError, error) {
+	f, err := os.Open(inPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	reader := NewCSVReader(f)
+	packages, err := reader.ReadAll()
+	return packages, reader.Malformed, err
+}
+
+// ReadAll reads all remaining rows and returns the packages they describe, with all rows of a package in a single
+// PackageInfo wherever they are in the input. Only errors that make it impossible to continue reading, such as a
+// missing header or a failing reader, are returned; malformed rows end up in Malformed.
+func (c *CSVReader) ReadAll() ([]graph.PackageInfo, error) {
+	packages := make(map[string]*graph.PackageInfo)
+	var order []string // Package names in the order they were first seen, so the output is deterministic
+	for {
+		row, err := c.readRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkg, ok := packages[row.name]
+		if !ok {
+			pkg = newCSVPackage(row.name)
+			packages[row.name] = pkg
+			order = append(order, row.name)
+		}
+		c.addRow(pkg, row)
+	}
+
+	result := make([]graph.PackageInfo, 0, len(order))
+	for _, name := range order {
+		result = append(result, *packages[name])
+	}
+	return result, nil
+}
+
+// Next returns the next package as soon as a row of another package follows its rows, so only one package is kept in
+// memory when the rows of every package are next to each other, as they are in the exported datasets. The rows of a
+// package that was returned already are kept until the end of the input, and then returned as another PackageInfo
+// with the same name, which graph.CreateGraph merges into the first.
+func (c *CSVReader) Next() (graph.PackageInfo, error) {
+	for !c.done {
+		row, err := c.readRow()
+		if err == io.EOF {
+			c.done = true
+			break
+		}
+		if err != nil {
+			return graph.PackageInfo{}, err
+		}
+		switch {
+		case c.current != nil && row.name == c.current.Name:
+			c.addRow(c.current, row)
+		case c.returned[row.name]:
+			pkg, ok := c.late[row.name]
+			if !ok {
+				pkg = newCSVPackage(row.name)
+				c.late[row.name] = pkg
+				c.lateOrder = append(c.lateOrder, row.name)
+			}
+			c.addRow(pkg, row)
+		default:
+			previous := c.current
+			c.current = newCSVPackage(row.name)
+			c.addRow(c.current, row)
+			if previous != nil {
+				c.returned[previous.Name] = true
+				return *previous, nil
+			}
+		}
+	}
+
+	if c.current != nil {
+		next := *c.current
+		c.returned[next.Name] = true
+		c.current = nil
+		return next, nil
+	}
+	if len(c.lateOrder) > 0 {
+		next := c.late[c.lateOrder[0]]
+		delete(c.late, c.lateOrder[0])
+		c.lateOrder = c.lateOrder[1:]
+		return *next, nil
+	}
+	return graph.PackageInfo{}, io.EOF
+}
+
+func (c *CSVReader) Skipped() []RowError {
+	return c.Malformed
+}
+
+func (c *CSVReader) Close() error {
+	return nil
+}
+
+// readRow returns the next well-formed row, reading the header first if it has not been read yet. Malformed rows
+// are recorded in Malformed and skipped.
+func (c *CSVReader) readRow() (csvRow, error) {
+	if c.columns == nil {
+		if err := c.readHeader(); err != nil {
+			return csvRow{}, err
+		}
+	}
+	for {
+		record, err := c.r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				c.Malformed = append(c.Malformed, RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+				continue
+			}
+			return csvRow{}, err
+		}
+		row, err := c.parseRecord(record)
+		if err != nil {
+			line, _ := c.r.FieldPos(0)
+			c.Malformed = append(c.Malformed, RowError{Line: line, Err: err})
+			continue
+		}
+		return row, nil
+	}
+}
+
+func (c *CSVReader) readHeader() error {
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return errors.New("csv input is empty, expected a header")
+	}
+	if err != nil {
+		return err
+	}
+
+	c.columns = make(map[string]int, len(header))
+	for i, column := range header {
+		c.columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range csvRequiredColumns {
+		if _, ok := c.columns[column]; !ok {
+			return fmt.Errorf("csv header is missing the %q column", column)
+		}
+	}
+	return nil
+}
+
+func (c *CSVReader) parseRecord(record []string) (csvRow, error) {
+	if len(record) != len(c.columns) {
+		return csvRow{}, fmt.Errorf("expected %d fields, got %d", len(c.columns), len(record))
+	}
+
+	row := csvRow{
+		name:       strings.TrimSpace(record[c.columns[csvName]]),
+		version:    strings.TrimSpace(record[c.columns[csvVersion]]),
+		timestamp:  strings.TrimSpace(record[c.columns[csvUploadTime]]),
+		dependency: strings.TrimSpace(record[c.columns[csvDependency]]),
+		constraint: strings.TrimSpace(record[c.columns[csvDependencyVersion]]),
+		record:     record,
+	}
+	if row.name == "" {
+		return csvRow{}, errors.New("package name is empty")
+	}
+	if row.version == "" {
+		return csvRow{}, fmt.Errorf("version of package %s is empty", row.name)
+	}
+	return row, nil
+}
+
+func newCSVPackage(name string) *graph.PackageInfo {
+	return &graph.PackageInfo{Name: name, Versions: make(map[string]graph.VersionInfo)}
+}
+
+// addRow adds the version and dependency of the row to the package.
+func (c *CSVReader) addRow(pkg *graph.PackageInfo, row csvRow) {
+	versionInfo, ok := pkg.Versions[row.version]
+	if !ok {
+		versionInfo = graph.VersionInfo{Timestamp: row.timestamp, Dependencies: make(map[string]string)}
+	} else if versionInfo.Timestamp == "" {
+		versionInfo.Timestamp = row.timestamp
+	}
+
+	versionInfo.Author = c.optionalField(row.record, csvAuthor, versionInfo.Author)
+	versionInfo.License = c.optionalField(row.record, csvLicense, versionInfo.License)
+	versionInfo.Repository = c.optionalField(row.record, csvRepository, versionInfo.Repository)
+	versionInfo.Description = c.optionalField(row.record, csvDescription, versionInfo.Description)
+
+	if row.dependency != "" {
+		constraint := row.constraint
+		if constraint == "" {
+			constraint = "*" // An unconstrained dependency accepts every version
+		}
+		versionInfo.Dependencies[row.dependency] = constraint
+	}
+	pkg.Versions[row.version] = versionInfo
+}
+
+// optionalField returns the value of an optional column of the record, or current if the column is missing or empty.
+// Like the timestamp, the metadata of a version is taken from the first row that has it.
+func (c *CSVReader) optionalField(record []string, column string, current string) string {
+	i, ok := c.columns[column]
+	if !ok || current != "" {
+		return current
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func TestCSVReaderGroupsRowsPerPackage(t *testing.T) {
+	input := `name,version,upload_time,dependency,dependency_version,author
+B,1.0.0,2021-04-22T20:15:37,A,>=1.0.0,Someone
+B,1.0.0,2021-04-22T20:15:37,C,*,Someone
+B,2.0.0,2021-05-22T20:15:37,A,,Someone
+A,1.0.0,2021-04-01T20:15:37,,,Someone else
+`
+	reader := NewCSVReader(strings.NewReader(input))
+	packages, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Creates one PackageInfo per package name", func(t *testing.T) {
+		if len(packages) != 2 {
+			t.Fatalf("Expected 2 packages, got %d", len(packages))
+		}
+		if packages[0].Name != "B" || packages[1].Name != "A" {
+			t.Errorf("Expected packages B and A in input order, got %s and %s", packages[0].Name, packages[1].Name)
+		}
+	})
+
+	t.Run("Groups the dependencies of every version", func(t *testing.T) {
+		b := packages[0]
+		if len(b.Versions) != 2 {
+			t.Fatalf("Expected 2 versions of B, got %d", len(b.Versions))
+		}
+		if deps := b.Versions["1.0.0"].Dependencies; len(deps) != 2 || deps["A"] != ">=1.0.0" || deps["C"] != "*" {
+			t.Errorf("Unexpected dependencies for B-1.0.0: %v", deps)
+		}
+		if constraint := b.Versions["2.0.0"].Dependencies["A"]; constraint != "*" {
+			t.Errorf("Expected an empty constraint to become *, got %q", constraint)
+		}
+		if timestamp := b.Versions["2.0.0"].Timestamp; timestamp != "2021-05-22T20:15:37" {
+			t.Errorf("Unexpected timestamp for B-2.0.0: %s", timestamp)
+		}
+		if author := b.Versions["1.0.0"].Author; author != "Someone" {
+			t.Errorf("Unexpected author for B-1.0.0: %s", author)
+		}
+	})
+
+	t.Run("Creates versions without dependencies", func(t *testing.T) {
+		a := packages[1]
+		if version, ok := a.Versions["1.0.0"]; !ok || len(version.Dependencies) != 0 {
+			t.Errorf("Expected A-1.0.0 without dependencies, got %v", a.Versions)
+		}
+	})
+}
+
+func TestCSVReaderReportsMalformedRows(t *testing.T) {
+	input := `name,version,upload_time,dependency,dependency_version,author
+B,1.0.0,2021-04-22T20:15:37,A,>=1.0.0,Someone
+B,1.0.0,2021-04-22T20:15:37
+,1.0.0,2021-04-22T20:15:37,A,*,Someone
+C,1.0.0,2021-04-22T20:15:37,"A,*,Someone
+`
+	reader := NewCSVReader(strings.NewReader(input))
+	packages, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(packages) != 1 || len(packages[0].Versions["1.0.0"].Dependencies) != 1 {
+		t.Errorf("Expected only the valid row to be read, got %v", packages)
+	}
+
+	expectedLines := []int{3, 4, 5}
+	if len(reader.Malformed) != len(expectedLines) {
+		t.Fatalf("Expected %d malformed rows, got %d (%v)", len(expectedLines), len(reader.Malformed), reader.Malformed)
+	}
+	for i, line := range expectedLines {
+		if reader.Malformed[i].Line != line {
+			t.Errorf("Expected malformed row %d to be on line %d, got %d", i, line, reader.Malformed[i].Line)
+		}
+	}
+}
+
+func TestCSVReaderRequiresHeader(t *testing.T) {
+	reader := NewCSVReader(strings.NewReader("name,version\nB,1.0.0\n"))
+	if _, err := reader.ReadAll(); err == nil {
+		t.Error("Expected an error for a header without the dependency columns")
+	}
+}
+
+// failingReader fails every read, like an input that breaks off.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestCSVReaderStreamsPackages(t *testing.T) {
+	input := `name,version,upload_time,dependency,dependency_version
+B,1.0.0,2021-04-22T20:15:37,A,>=1.0.0
+B,1.0.0,2021-04-22T20:15:37,C,*
+A,1.0.0,2021-04-01T20:15:37,,
+`
+	reader := NewCSVReader(io.MultiReader(strings.NewReader(input), failingReader{}))
+	b, err := reader.Next()
+	if err != nil || b.Name != "B" || len(b.Versions["1.0.0"].Dependencies) != 2 {
+		t.Fatalf("Expected B with 2 dependencies before the input broke off, got %+v, %v", b, err)
+	}
+	if _, err := reader.Next(); err == nil {
+		t.Error("Expected the error of the input once the rows of A were needed")
+	}
+}
+
+func TestCSVReaderReturnsOutOfOrderRowsLast(t *testing.T) {
+	input := `name,version,upload_time,dependency,dependency_version
+B,1.0.0,2021-04-22T20:15:37,A,>=1.0.0
+A,1.0.0,2021-04-01T20:15:37,,
+B,1.0.0,2021-04-22T20:15:37,C,*
+B,2.0.0,2021-05-22T20:15:37,,
+`
+	reader := NewCSVReader(strings.NewReader(input))
+	var packages []graph.PackageInfo
+	for {
+		pkg, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		packages = append(packages, pkg)
+	}
+	if len(packages) != 3 || packages[0].Name != "B" || packages[1].Name != "A" || packages[2].Name != "B" {
+		t.Fatalf("Expected B, A and the late rows of B, got %+v", packages)
+	}
+	if deps := packages[0].Versions["1.0.0"].Dependencies; len(deps) != 1 || deps["A"] != ">=1.0.0" {
+		t.Errorf("Expected the first B-1.0.0 to depend on A, got %v", deps)
+	}
+	if late := packages[2]; late.Versions["1.0.0"].Dependencies["C"] != "*" || len(late.Versions) != 2 {
+		t.Errorf("Expected the late rows of B to add a dependency on C and B-2.0.0, got %+v", late.Versions)
+	}
+}
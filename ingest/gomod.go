@@ -0,0 +1,441 @@
+package ingest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/Masterminds/semver"
+)
+
+func init() {
+	Register(Format{
+		Name:      "go",
+		Dir:       isModuleProxy,
+		OpenDir:   NewGoProxyReader,
+		Ecosystem: graph.Go,
+	})
+}
+
+// proxyVersionDir is the directory of a module in a module proxy that holds its list, .info, .mod and .zip files.
+const proxyVersionDir = "@v"
+
+var errFoundModule = errors.New("found a module")
+
+// isModuleProxy reports whether the directory tree at path contains at least one module laid out the way the
+// GOPROXY protocol serves it.
+func isModuleProxy(path string) bool {
+	err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() && entry.Name() == proxyVersionDir {
+			return errFoundModule
+		}
+		return nil
+	})
+	return err == errFoundModule
+}
+
+// unescapeModulePath reverses the case encoding of module paths on disk and in proxy URLs, in which every upper case
+// letter is written as an exclamation mark followed by the lower case letter.
+func unescapeModulePath(escaped string) (string, error) {
+	var b strings.Builder
+	bang := false
+	for _, c := range escaped {
+		switch {
+		case bang:
+			if c < 'a' || c > 'z' {
+				return "", fmt.Errorf("invalid escaped module path %q", escaped)
+			}
+			b.WriteRune(unicode.ToUpper(c))
+			bang = false
+		case c == '!':
+			bang = true
+		case c >= 'A' && c <= 'Z':
+			return "", fmt.Errorf("invalid escaped module path %q", escaped)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if bang {
+		return "", fmt.Errorf("invalid escaped module path %q", escaped)
+	}
+	return b.String(), nil
+}
+
+// moduleVersion is a module path with a version, which is empty in replace directives that replace every version.
+type moduleVersion struct {
+	path, version string
+}
+
+// goMod holds the directives of a go.mod file that determine the dependencies of a module.
+type goMod struct {
+	module   string
+	requires []moduleVersion
+	replaces map[moduleVersion]moduleVersion
+	excludes map[moduleVersion]bool
+}
+
+// parseGoMod parses the module, require, replace and exclude directives of a go.mod file, in both their single line
+// and their block form. Other directives are ignored.
+func parseGoMod(data []byte) (*goMod, error) {
+	mod := &goMod{replaces: make(map[moduleVersion]moduleVersion), excludes: make(map[moduleVersion]bool)}
+	block := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if comment := strings.Index(text, "//"); comment >= 0 {
+			text = text[:comment]
+		}
+		fields, err := goModFields(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+		if err := mod.directive(verb, fields); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mod.module == "" {
+		return nil, errors.New("no module directive")
+	}
+	return mod, nil
+}
+
+// goModFields splits a line of a go.mod file into its fields, unquoting quoted module paths.
+func goModFields(line string) ([]string, error) {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] == '"' || line[0] == '`' {
+			end := strings.IndexByte(line[1:], line[0])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string %s", line)
+			}
+			field, err := strconv.Unquote(line[:end+2])
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+			line = line[end+2:]
+			continue
+		}
+		end := strings.IndexFunc(line, unicode.IsSpace)
+		if end < 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+	return fields, nil
+}
+
+func (mod *goMod) directive(verb string, fields []string) error {
+	switch verb {
+	case "module":
+		if len(fields) != 1 {
+			return errors.New("usage: module path")
+		}
+		mod.module = fields[0]
+	case "require":
+		if len(fields) != 2 {
+			return errors.New("usage: require module/path v1.2.3")
+		}
+		mod.requires = append(mod.requires, moduleVersion{fields[0], fields[1]})
+	case "exclude":
+		if len(fields) != 2 {
+			return errors.New("usage: exclude module/path v1.2.3")
+		}
+		mod.excludes[moduleVersion{fields[0], fields[1]}] = true
+	case "replace":
+		arrow := -1
+		for i, field := range fields {
+			if field == "=>" {
+				arrow = i
+			}
+		}
+		if arrow < 1 || arrow > 2 || len(fields)-arrow-1 < 1 || len(fields)-arrow-1 > 2 {
+			return errors.New("usage: replace module/path [v1.2.3] => other/module v1.4.5 or local/directory")
+		}
+		old := moduleVersion{path: fields[0]}
+		if arrow == 2 {
+			old.version = fields[1]
+		}
+		replacement := moduleVersion{path: fields[arrow+1]}
+		if len(fields) == arrow+3 {
+			replacement.version = fields[arrow+2]
+		}
+		mod.replaces[old] = replacement
+	}
+	return nil
+}
+
+// replace returns the module version that requirement is replaced with, and false if it is replaced by a directory.
+func (mod *goMod) replace(requirement moduleVersion) (moduleVersion, bool) {
+	replacement, ok := mod.replaces[requirement]
+	if !ok {
+		replacement, ok = mod.replaces[moduleVersion{path: requirement.path}]
+	}
+	if !ok {
+		return requirement, true
+	}
+	return replacement, replacement.version != ""
+}
+
+// proxyModule is a module of the proxy with the go.mod files and timestamps of its versions.
+type proxyModule struct {
+	path     string
+	versions map[string]*proxyVersion
+	sorted   []string // Versions from lowest to highest
+}
+
+type proxyVersion struct {
+	timestamp time.Time
+	mod       *goMod
+}
+
+// GoProxyReader reads a directory laid out like a GOPROXY mirror, such as $GOPATH/pkg/mod/cache/download or the
+// output of a proxy crawler. Every module version in an @v/list file, or with an .info or .mod file, becomes a version
+// of the package named by the module path. Its timestamp is the Time of its .info file, or else the modification time
+// of its .mod file. The require directives of the .mod file are its dependencies, and each is constrained to exactly
+// the required version, as minimal version selection does. Every module version is treated as the main module of its
+// own build, so its replace directives redirect requirements to other module versions (replacements by local
+// directories are left out), and a requirement on an excluded version moves to the next version the proxy has.
+// Versions without a .mod file have no dependencies.
+type GoProxyReader struct {
+	root string
+	read bool
+
+	result    []graph.PackageInfo
+	Malformed []RowError
+}
+
+// NewGoProxyReader creates a GoProxyReader for the directory at root. The directory is read on the first call to Next.
+func NewGoProxyReader(root string) (Source, error) {
+	return &GoProxyReader{root: root}, nil
+}
+
+func (p *GoProxyReader) Next() (graph.PackageInfo, error) {
+	if !p.read {
+		if err := p.readProxy(); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		p.read = true
+	}
+	if len(p.result) == 0 {
+		return graph.PackageInfo{}, io.EOF
+	}
+	next := p.result[0]
+	p.result = p.result[1:]
+	return next, nil
+}
+
+func (p *GoProxyReader) Skipped() []RowError {
+	return p.Malformed
+}
+
+// Close does nothing, since every file of the directory is closed as soon as it has been read.
+func (p *GoProxyReader) Close() error {
+	return nil
+}
+
+func (p *GoProxyReader) skip(path string, err error) {
+	if rel, relErr := filepath.Rel(p.root, path); relErr == nil {
+		path = rel
+	}
+	p.Malformed = append(p.Malformed, RowError{File: path, Err: err})
+}
+
+// readProxy reads every module of the proxy. All of them are needed before the first package can be returned, since
+// excluded requirements are moved to the next version of the required module.
+func (p *GoProxyReader) readProxy() error {
+	modules := make(map[string]*proxyModule)
+	err := filepath.WalkDir(p.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || entry.Name() != proxyVersionDir {
+			return nil
+		}
+		escaped, err := filepath.Rel(p.root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		modulePath, err := unescapeModulePath(filepath.ToSlash(escaped))
+		if err != nil {
+			p.skip(path, err)
+			return filepath.SkipDir
+		}
+		modules[modulePath] = p.readModule(modulePath, path)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(modules))
+	for path := range modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		module := modules[path]
+		if len(module.versions) == 0 {
+			continue
+		}
+		pkg := graph.PackageInfo{Name: path, Versions: make(map[string]graph.VersionInfo, len(module.versions))}
+		for version, info := range module.versions {
+			dependencies := make(map[string]string)
+			if info.mod != nil {
+				for _, requirement := range info.mod.requires {
+					requirement, ok := info.mod.replace(requirement)
+					if !ok {
+						continue
+					}
+					if info.mod.excludes[requirement] {
+						requirement.version = nextAllowedVersion(modules[requirement.path], requirement.version, info.mod.excludes)
+					}
+					if requirement.version != "" {
+						dependencies[requirement.path] = requirement.version
+					}
+				}
+			}
+			pkg.Versions[version] = graph.VersionInfo{
+				Timestamp:    info.timestamp.UTC().Format(time.RFC3339),
+				Dependencies: dependencies,
+			}
+		}
+		p.result = append(p.result, pkg)
+	}
+	return nil
+}
+
+// readModule reads the versions of the module whose @v directory is at dir.
+func (p *GoProxyReader) readModule(modulePath, dir string) *proxyModule {
+	module := &proxyModule{path: modulePath, versions: make(map[string]*proxyVersion)}
+	version := func(v string) *proxyVersion {
+		info, ok := module.versions[v]
+		if !ok {
+			info = &proxyVersion{}
+			module.versions[v] = info
+		}
+		return info
+	}
+
+	var listTime time.Time
+	if list, err := os.ReadFile(filepath.Join(dir, "list")); err == nil {
+		for _, line := range strings.Split(string(list), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				version(fields[0])
+			}
+		}
+		if stat, err := os.Stat(filepath.Join(dir, "list")); err == nil {
+			listTime = stat.ModTime()
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		p.skip(dir, err)
+		return module
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		switch filepath.Ext(name) {
+		case ".info":
+			var info struct {
+				Time time.Time
+			}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				err = json.Unmarshal(data, &info)
+			}
+			if err != nil {
+				p.skip(path, err)
+				continue
+			}
+			version(strings.TrimSuffix(name, ".info")).timestamp = info.Time
+		case ".mod":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				p.skip(path, err)
+				continue
+			}
+			mod, err := parseGoMod(data)
+			if err != nil {
+				p.skip(path, err)
+				continue
+			}
+			v := version(strings.TrimSuffix(name, ".mod"))
+			v.mod = mod
+			if stat, err := entry.Info(); err == nil && v.timestamp.IsZero() {
+				v.timestamp = stat.ModTime()
+			}
+		}
+	}
+
+	for v, info := range module.versions {
+		if info.timestamp.IsZero() {
+			info.timestamp = listTime
+		}
+		module.sorted = append(module.sorted, v)
+	}
+	sort.Slice(module.sorted, func(i, j int) bool {
+		return compareGoVersions(module.sorted[i], module.sorted[j]) < 0
+	})
+	return module
+}
+
+// compareGoVersions orders module versions by semantic versioning, putting versions that are not valid last.
+func compareGoVersions(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+	return va.Compare(vb)
+}
+
+// nextAllowedVersion returns the lowest version of the module above version that is not excluded, or "" if the
+// proxy has none.
+func nextAllowedVersion(module *proxyModule, version string, excludes map[moduleVersion]bool) string {
+	if module == nil {
+		return ""
+	}
+	for _, candidate := range module.sorted {
+		if compareGoVersions(candidate, version) > 0 && !excludes[moduleVersion{module.path, candidate}] {
+			return candidate
+		}
+	}
+	return ""
+}
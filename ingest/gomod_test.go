@@ -0,0 +1,48 @@
+package ingest
+
+import "testing"
+
+func TestGoProxyReader(t *testing.T) {
+	if format, err := Detect("testdata/goproxy"); err != nil || format.Name != "go" {
+		t.Fatalf("Expected the fixture to be detected as a module proxy, got %v (%v)", format.Name, err)
+	}
+	packages := readPackages(t, "testdata/goproxy")
+
+	t.Run("Unescapes module paths", func(t *testing.T) {
+		toml, ok := packages["github.com/BurntSushi/toml"]
+		if !ok || len(toml.Versions) != 2 {
+			t.Fatalf("Expected 2 versions of github.com/BurntSushi/toml, got %v", toml)
+		}
+		if timestamp := toml.Versions["v0.4.0"].Timestamp; timestamp != "2021-06-08T10:00:00Z" {
+			t.Errorf("Unexpected timestamp for v0.4.0: %s", timestamp)
+		}
+	})
+
+	t.Run("Requires exact versions", func(t *testing.T) {
+		dependencies := packages["example.com/app"].Versions["v1.0.0"].Dependencies
+		if len(dependencies) != 1 || dependencies["github.com/BurntSushi/toml"] != "v0.4.0" {
+			t.Errorf("Unexpected dependencies %v", dependencies)
+		}
+	})
+
+	t.Run("Applies replace and exclude directives", func(t *testing.T) {
+		dependencies := packages["example.com/app"].Versions["v1.1.0"].Dependencies
+		expected := map[string]string{"github.com/BurntSushi/toml": "v0.4.1", "golang.org/x/text": "v0.3.7"}
+		if len(dependencies) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, dependencies)
+		}
+		for path, version := range expected {
+			if dependencies[path] != version {
+				t.Errorf("Expected %s %s, got %s", path, version, dependencies[path])
+			}
+		}
+	})
+}
+
+func TestParseGoModErrors(t *testing.T) {
+	for _, invalid := range []string{"go 1.18\n", "module a\nrequire b\n", "module a\nreplace b => \n"} {
+		if _, err := parseGoMod([]byte(invalid)); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
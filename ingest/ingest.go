@@ -1 +1,219 @@
+// Package ingest contains readers that turn package metadata in various formats into the graph.PackageInfo
+// structures the graph is built from. Every reader registers itself as a Format, so that Open can pick the right one
+// for a file based on its extension or, if that is not conclusive, on its content.
 package ingest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+// sniffLength is the amount of bytes of a file that is handed to the Sniff functions of the formats.
+const sniffLength = 4096
+
+// RowError describes a single malformed record of an input file. The reader that produced it skips the record and
+// continues with the next one, so a handful of bad rows do not prevent the rest of the file from being used. File is
+// only set by readers of directories, which read many files.
+type RowError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string {
+	if e.File != "" && e.Line == 0 {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// Source is a stream of packages read from an input file. It can be passed to graph.CreateGraph directly.
+type Source interface {
+	graph.PackageSource
+	// Skipped returns the malformed records that were skipped so far.
+	Skipped() []RowError
+	io.Closer
+}
+
+// Format describes a registered input format.
+type Format struct {
+	Name string
+	// Extensions are the file name suffixes, including the dot, that are read with this format.
+	Extensions []string
+	// Sniff reports whether the start of a file looks like this format. It is used when the extension of a file is
+	// unknown or shared by multiple formats.
+	Sniff func(header []byte) bool
+	// New creates a Source reading from r.
+	New func(r io.Reader) (Source, error)
+	// Dir reports whether the directory at path is laid out the way this format expects. It is only set for formats
+	// that read directories instead of single files.
+	Dir func(path string) bool
+	// OpenDir creates a Source reading the directory at path.
+	OpenDir func(path string) (Source, error)
+	// Ecosystem is the ecosystem the packages read with this format come from, or nil if the format does not tell.
+	Ecosystem graph.Ecosystem
+}
+
+var formats []Format
+
+// Register adds a format to the registry. Formats registered earlier take precedence when multiple formats match.
+func Register(format Format) {
+	formats = append(formats, format)
+}
+
+// Formats returns all registered formats.
+func Formats() []Format {
+	return formats
+}
+
+// Extensions returns the file extensions of all registered formats, including their compressed variants.
+func Extensions() []string {
+	var extensions []string
+	for _, format := range formats {
+		for _, extension := range format.Extensions {
+			extensions = append(extensions, extension)
+			for _, c := range compressions {
+				extensions = append(extensions, extension+c.extension)
+			}
+		}
+	}
+	return extensions
+}
+
+func (format Format) matchesExtension(fileName string) bool {
+	for _, extension := range format.Extensions {
+		if strings.HasSuffix(strings.ToLower(fileName), extension) {
+			return true
+		}
+	}
+	return false
+}
+
+func (format Format) sniff(header []byte) bool {
+	return format.Sniff != nil && format.Sniff(header)
+}
+
+// detectDir picks the format of a directory.
+func detectDir(path string) (Format, error) {
+	for _, format := range formats {
+		if format.Dir != nil && format.Dir(path) {
+			return format, nil
+		}
+	}
+	return Format{}, fmt.Errorf("no reader found for directory %s", filepath.Base(path))
+}
+
+// detect picks the format of a file from its name and the first bytes of its content.
+func detect(fileName string, header []byte) (Format, error) {
+	var candidates []Format
+	for _, format := range formats {
+		if format.New != nil && format.matchesExtension(fileName) {
+			candidates = append(candidates, format)
+		}
+	}
+	switch len(candidates) {
+	case 1:
+		return candidates[0], nil
+	case 0: // Unknown extension, only the content can decide
+		for _, format := range formats {
+			if format.New != nil && format.sniff(header) {
+				return format, nil
+			}
+		}
+		return Format{}, fmt.Errorf("no reader found for %s", filepath.Base(fileName))
+	default: // Shared extension, prefer the format that recognises the content
+		for _, format := range candidates {
+			if format.sniff(header) {
+				return format, nil
+			}
+		}
+		return candidates[0], nil
+	}
+}
+
+// Detect returns the format Open would use to read the file or directory at path.
+func Detect(path string) (Format, error) {
+	if info, err := os.Stat(path); err != nil {
+		return Format{}, err
+	} else if info.IsDir() {
+		return detectDir(path)
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return Format{}, err
+	}
+	defer in.Close()
+	return detect(in.name, in.header)
+}
+
+// fileSource closes the underlying file together with the source reading from it.
+type fileSource struct {
+	Source
+	in *input
+}
+
+func (s fileSource) Close() error {
+	err := s.Source.Close()
+	if inErr := s.in.Close(); err == nil {
+		err = inErr
+	}
+	return err
+}
+
+// Open opens the file or directory at path with the reader of its format. Compressed files are decompressed
+// transparently. The returned Source has to be closed by the caller.
+func Open(path string) (Source, error) {
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		format, err := detectDir(path)
+		if err != nil {
+			return nil, err
+		}
+		return format.OpenDir(path)
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	format, err := detect(in.name, in.header)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+
+	source, err := format.New(in.reader)
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("reading %s as %s: %w", filepath.Base(path), format.Name, err)
+	}
+	return fileSource{Source: source, in: in}, nil
+}
+
+// firstNonSpace returns the first byte of b that is not whitespace, or 0 if there is none.
+func firstNonSpace(b []byte) byte {
+	for _, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case 0xEF, 0xBB, 0xBF: // UTF-8 byte order mark
+			continue
+		}
+		return c
+	}
+	return 0
+}
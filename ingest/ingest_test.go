@@ -0,0 +1,150 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestDetectByExtensionAndContent(t *testing.T) {
+	dir := t.TempDir()
+	copyFile := func(from, to string) string {
+		content, err := os.ReadFile(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, to)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cases := []struct {
+		path   string
+		format string
+	}{
+		{"../data/input/test_data.json", "json"},
+		{"../data/input/dependencies.csv", "csv"},
+		{copyFile("../data/input/test_data.json", "packages"), "json"},
+		{copyFile("../data/input/dependencies.csv", "dependencies.txt"), "csv"},
+	}
+	for _, c := range cases {
+		format, err := Detect(c.path)
+		if err != nil {
+			t.Errorf("Could not detect the format of %s: %v", c.path, err)
+		} else if format.Name != c.format {
+			t.Errorf("Expected %s to be read as %s, got %s", c.path, c.format, format.Name)
+		}
+	}
+
+	unknown := filepath.Join(dir, "notes")
+	if err := os.WriteFile(unknown, []byte("nothing to see here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Detect(unknown); err == nil {
+		t.Error("Expected an error for a file without a known format")
+	}
+}
+
+func readNames(t *testing.T, path string) []string {
+	source, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	var names []string
+	for {
+		pkg, err := source.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, pkg.Name)
+	}
+}
+
+func TestOpenStreamsPackages(t *testing.T) {
+	names := readNames(t, "../data/input/test_data.json")
+	if len(names) != 3 || names[0] != "B" || names[1] != "C" || names[2] != "A" {
+		t.Errorf("Expected packages B, C and A, got %v", names)
+	}
+}
+
+func TestOpenCompressedAndJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	lines := []byte(`{"name": "A", "versions": {"1.0.0": {"timestamp": "2021-04-01T20:15:37", "dependencies": {}}}}
+
+{"name": "B", "versions": {"1.0.0": {"timestamp": "2021-04-22T20:15:37", "dependencies": {"A": "1.0.0"}}}}
+`)
+	doc, err := os.ReadFile("../data/input/test_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(lines)
+	gzipWriter.Close()
+
+	var zstdCompressed bytes.Buffer
+	zstdWriter, _ := zstd.NewWriter(&zstdCompressed)
+	zstdWriter.Write(doc)
+	zstdWriter.Close()
+
+	files := map[string][]byte{
+		"packages.jsonl":    lines,
+		"packages.jsonl.gz": gzipped.Bytes(),
+		"packages.json.zst": zstdCompressed.Bytes(),
+		"packages":          gzipped.Bytes(), // Neither the compression nor the format can be seen from the name
+	}
+	expected := map[string]int{
+		"packages.jsonl":    2,
+		"packages.jsonl.gz": 2,
+		"packages.json.zst": 3,
+		"packages":          2,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if names := readNames(t, path); len(names) != expected[name] {
+			t.Errorf("Expected %d packages in %s, got %v", expected[name], name, names)
+		}
+	}
+}
+
+func TestJSONLinesReaderSkipsMalformedLines(t *testing.T) {
+	input := `{"name": "A", "versions": {}}
+{"name": "B", "versions":
+{"versions": {}}
+{"name": "C", "versions": {}}`
+	reader := NewJSONLinesReader(bytes.NewBufferString(input))
+
+	var names []string
+	for {
+		pkg, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, pkg.Name)
+	}
+	if len(names) != 2 || names[0] != "A" || names[1] != "C" {
+		t.Errorf("Expected packages A and C, got %v", names)
+	}
+	if len(reader.Malformed) != 2 || reader.Malformed[0].Line != 2 || reader.Malformed[1].Line != 3 {
+		t.Errorf("Expected lines 2 and 3 to be reported, got %v", reader.Malformed)
+	}
+}
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+	"github.com/mailru/easyjson"
+)
+
+func init() {
+	Register(Format{
+		Name:       "json",
+		Extensions: []string{".json"},
+		Sniff:      sniffDoc,
+		New:        NewDocReader,
+	})
+}
+
+// sniffDoc recognises the {"pkgs":[...]} document described in the README.
+func sniffDoc(header []byte) bool {
+	return firstNonSpace(header) == '{' && bytes.Contains(header, []byte(`"pkgs"`))
+}
+
+// DocReader streams a graph.Doc, the {"pkgs":[...]} document described in the README. Only the package that is
+// currently being decoded is kept in memory, so the document as a whole never is. The lexer of easyjson needs its
+// whole input in memory, so the document is walked with the decoder of encoding/json, and every package is decoded
+// with easyjson on its own, like graph.ParseJSON decodes the document.
+type DocReader struct {
+	dec     *json.Decoder
+	inArray bool // Whether the decoder is positioned inside the pkgs array
+	done    bool
+}
+
+// NewDocReader creates a DocReader reading from r and checks that r starts with a JSON object.
+func NewDocReader(r io.Reader) (Source, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	return &DocReader{dec: dec}, nil
+}
+
+// expectDelim reads the next token of dec and fails if it is not the delimiter delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v at offset %d, got %v", delim, dec.InputOffset(), token)
+	}
+	return nil
+}
+
+func (d *DocReader) Next() (graph.PackageInfo, error) {
+	for !d.done {
+		if !d.inArray {
+			if err := d.seekPkgs(); err != nil {
+				return graph.PackageInfo{}, err
+			}
+			continue
+		}
+		if !d.dec.More() { // Consume the closing bracket of the pkgs array and skip whatever else the document contains
+			if _, err := d.dec.Token(); err != nil {
+				return graph.PackageInfo{}, err
+			}
+			d.inArray = false
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := d.dec.Decode(&raw); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		var pkg graph.PackageInfo
+		if err := easyjson.Unmarshal(raw, &pkg); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		return pkg, nil
+	}
+	return graph.PackageInfo{}, io.EOF
+}
+
+// seekPkgs skips the fields of the document until the decoder is positioned inside the pkgs array. If the document
+// has no more pkgs arrays, the reader is marked as done.
+func (d *DocReader) seekPkgs() error {
+	for d.dec.More() {
+		token, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		if token == "pkgs" {
+			if err := expectDelim(d.dec, '['); err != nil {
+				return err
+			}
+			d.inArray = true
+			return nil
+		}
+		var skipped json.RawMessage
+		if err := d.dec.Decode(&skipped); err != nil {
+			return err
+		}
+	}
+	d.done = true
+	return nil
+}
+
+// Skipped returns nil, since the reader stops at the first package it cannot decode.
+func (d *DocReader) Skipped() []RowError {
+	return nil
+}
+
+func (d *DocReader) Close() error {
+	return nil
+}
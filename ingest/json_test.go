@@ -0,0 +1,47 @@
+package ingest
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDocReaderSkipsOtherFields(t *testing.T) {
+	input := `{"source": {"name": "test", "pkgs": []}, "pkgs": [
+		{"name": "A", "versions": {"1.0.0": {"timestamp": "2021-04-01T20:15:37", "dependencies": {}}}},
+		{"name": "B", "versions": {"1.0.0": {"timestamp": "2021-04-22T20:15:37", "dependencies": {"A": "1.0.0"}}}}
+	], "count": 2}`
+	source, err := NewDocReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for {
+		pkg, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, pkg.Name)
+	}
+	if len(names) != 2 || names[0] != "A" || names[1] != "B" {
+		t.Errorf("Expected packages A and B, got %v", names)
+	}
+}
+
+func TestDocReaderReportsInvalidDocuments(t *testing.T) {
+	if _, err := NewDocReader(strings.NewReader(`[{"name": "A"}]`)); err == nil {
+		t.Error("Expected an error for a document that is not an object")
+	}
+
+	source, err := NewDocReader(strings.NewReader(`{"pkgs": [{"name": "A", "versions": 5}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Next(); err == nil || err == io.EOF {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
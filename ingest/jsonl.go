@@ -0,0 +1,83 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func init() {
+	Register(Format{
+		Name:       "jsonl",
+		Extensions: []string{".jsonl", ".ndjson"},
+		Sniff:      sniffJSONLines,
+		New: func(r io.Reader) (Source, error) {
+			return NewJSONLinesReader(r), nil
+		},
+	})
+}
+
+// sniffJSONLines recognises newline-delimited PackageInfo objects by looking for a first line that is an object
+// with the fields of a PackageInfo. Lines of npm registry dumps have those fields as well, so they are excluded.
+func sniffJSONLines(header []byte) bool {
+	header = bytes.TrimLeft(header, " \t\r\n")
+	firstLine := header
+	if end := bytes.IndexByte(header, '\n'); end >= 0 {
+		firstLine = header[:end]
+	}
+	return firstNonSpace(firstLine) == '{' && !sniffNpm(firstLine) &&
+		bytes.Contains(firstLine, []byte(`"name"`)) && bytes.Contains(firstLine, []byte(`"versions"`))
+}
+
+// JSONLinesReader reads newline-delimited JSON with one PackageInfo object per line. Lines that cannot be decoded are
+// skipped and recorded in Malformed.
+type JSONLinesReader struct {
+	r    *bufio.Reader
+	line int
+
+	Malformed []RowError
+}
+
+// NewJSONLinesReader creates a JSONLinesReader reading from r.
+func NewJSONLinesReader(r io.Reader) *JSONLinesReader {
+	return &JSONLinesReader{r: bufio.NewReader(r)}
+}
+
+func (j *JSONLinesReader) Next() (graph.PackageInfo, error) {
+	for {
+		line, err := j.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return graph.PackageInfo{}, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return graph.PackageInfo{}, io.EOF
+		}
+		j.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var pkg graph.PackageInfo
+		if decodeErr := pkg.UnmarshalJSON(line); decodeErr != nil {
+			j.Malformed = append(j.Malformed, RowError{Line: j.line, Err: decodeErr})
+			continue
+		}
+		if pkg.Name == "" {
+			j.Malformed = append(j.Malformed, RowError{Line: j.line, Err: errors.New("package name is empty")})
+			continue
+		}
+		return pkg, nil
+	}
+}
+
+func (j *JSONLinesReader) Skipped() []RowError {
+	return j.Malformed
+}
+
+func (j *JSONLinesReader) Close() error {
+	return nil
+}
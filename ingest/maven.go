@@ -0,0 +1,484 @@
+package ingest
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func init() {
+	Register(Format{
+		Name:      "maven",
+		Dir:       isMavenRepository,
+		OpenDir:   NewMavenReader,
+		Ecosystem: graph.Maven,
+	})
+}
+
+// mavenTimestampLayout is the layout of the lastUpdated field of maven-metadata.xml.
+const mavenTimestampLayout = "20060102150405"
+
+// maxInterpolationDepth bounds the amount of times properties referring to other properties are expanded.
+const maxInterpolationDepth = 10
+
+var propertyReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+var errFoundPom = errors.New("found a pom")
+
+// isMavenRepository reports whether there is at least one .pom file in the directory tree at path.
+func isMavenRepository(path string) bool {
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(entry.Name(), ".pom") {
+			return errFoundPom
+		}
+		return nil
+	})
+	return err == errFoundPom
+}
+
+// pomProperties collects the arbitrarily named children of the properties element.
+type pomProperties map[string]string
+
+func (p *pomProperties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = make(pomProperties)
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &element); err != nil {
+				return err
+			}
+			(*p)[element.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+}
+
+func (d pomDependency) key() string {
+	return d.GroupID + ":" + d.ArtifactID
+}
+
+type pomParent struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type pom struct {
+	Parent              *pomParent      `xml:"parent"`
+	GroupID             string          `xml:"groupId"`
+	ArtifactID          string          `xml:"artifactId"`
+	Version             string          `xml:"version"`
+	Properties          pomProperties   `xml:"properties"`
+	ManagedDependencies []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Dependencies        []pomDependency `xml:"dependencies>dependency"`
+	path                string
+	timestamp           time.Time
+}
+
+// coordinates returns the groupId, artifactId and version of the pom as written, taking the groupId and version of
+// the parent if the pom does not declare them itself.
+func (p *pom) coordinates() (string, string, string) {
+	groupID, version := p.GroupID, p.Version
+	if p.Parent != nil {
+		if groupID == "" {
+			groupID = p.Parent.GroupID
+		}
+		if version == "" {
+			version = p.Parent.Version
+		}
+	}
+	return strings.TrimSpace(groupID), strings.TrimSpace(p.ArtifactID), strings.TrimSpace(version)
+}
+
+// mavenModel is the effective model of a pom: its own declarations merged with everything it inherits from its
+// parents and imports from bills of materials, with all properties interpolated.
+type mavenModel struct {
+	groupID, artifactID, version string
+	properties                   map[string]string
+	rawManaged, rawDependencies  []pomDependency // As declared by the pom and its parents, without interpolation
+	managed                      map[string]pomDependency
+	dependencies                 []pomDependency
+}
+
+// MavenReader reads a local Maven repository, such as ~/.m2/repository, or any directory tree of .pom files. Parent
+// poms, dependency management (including imported bills of materials) and properties are resolved the way Maven
+// builds the effective pom. Every groupId:artifactId becomes a package. A repository keeps one maven-metadata.xml per
+// artifact, whose lastUpdated field is when its release was deployed, so that is the timestamp of the release. SNAPSHOT
+// versions have a maven-metadata.xml of their own next to their pom. Every other version gets the modification time of
+// its pom. Test and system scoped dependencies are left out, since they are not part of the dependency tree of dependents.
+type MavenReader struct {
+	root string
+	read bool
+
+	poms      map[string]*pom // Keyed by groupId:artifactId:version
+	models    map[string]*mavenModel
+	resolving map[string]bool
+
+	result    []graph.PackageInfo
+	Malformed []RowError
+}
+
+// NewMavenReader creates a MavenReader for the directory at root. The directory is read on the first call to Next.
+func NewMavenReader(root string) (Source, error) {
+	return &MavenReader{
+		root:      root,
+		poms:      make(map[string]*pom),
+		models:    make(map[string]*mavenModel),
+		resolving: make(map[string]bool),
+	}, nil
+}
+
+func (m *MavenReader) Next() (graph.PackageInfo, error) {
+	if !m.read {
+		if err := m.readRepository(); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		m.read = true
+	}
+	if len(m.result) == 0 {
+		return graph.PackageInfo{}, io.EOF
+	}
+	next := m.result[0]
+	m.result = m.result[1:]
+	return next, nil
+}
+
+func (m *MavenReader) Skipped() []RowError {
+	return m.Malformed
+}
+
+// Close does nothing, since every file of the directory is closed as soon as it has been read.
+func (m *MavenReader) Close() error {
+	return nil
+}
+
+func (m *MavenReader) skip(path string, err error) {
+	if rel, relErr := filepath.Rel(m.root, path); relErr == nil {
+		path = rel
+	}
+	m.Malformed = append(m.Malformed, RowError{File: path, Err: err})
+}
+
+// readRepository parses every pom under the root, resolves their effective models and groups them into packages.
+func (m *MavenReader) readRepository() error {
+	err := filepath.WalkDir(m.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pom") {
+			return nil
+		}
+		p, err := parsePom(path)
+		if err != nil {
+			m.skip(path, err)
+			return nil
+		}
+		groupID, artifactID, version := p.coordinates()
+		if groupID == "" || artifactID == "" || version == "" {
+			m.skip(path, errors.New("pom does not declare its groupId, artifactId and version"))
+			return nil
+		}
+		m.poms[groupID+":"+artifactID+":"+version] = p
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	packages := make(map[string]*graph.PackageInfo)
+	keys := make([]string, 0, len(m.poms))
+	for key := range m.poms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := m.poms[key]
+		model, err := m.resolve(key)
+		if err != nil {
+			m.skip(p.path, err)
+			continue
+		}
+		if strings.Contains(model.version, "${") {
+			m.skip(p.path, fmt.Errorf("version %s could not be interpolated", model.version))
+			continue
+		}
+
+		dependencies := make(map[string]string, len(model.dependencies))
+		for _, dependency := range model.dependencies {
+			if !dependency.inTree() {
+				continue
+			}
+			if dependency.Version == "" {
+				dependency.Version = "unspecified"
+			}
+			dependencies[dependency.key()] = dependency.Version
+		}
+
+		name := model.groupID + ":" + model.artifactID
+		pkg, ok := packages[name]
+		if !ok {
+			pkg = &graph.PackageInfo{Name: name, Versions: make(map[string]graph.VersionInfo)}
+			packages[name] = pkg
+		}
+		pkg.Versions[model.version] = graph.VersionInfo{
+			Timestamp:    p.timestamp.UTC().Format(time.RFC3339),
+			Dependencies: dependencies,
+		}
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.result = append(m.result, *packages[name])
+	}
+	return nil
+}
+
+// parsePom parses the pom at path and determines its timestamp.
+func parsePom(path string) (*pom, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var p pom
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil // Poms are practically always ASCII, even when they claim to be ISO-8859-1
+	}
+	if err := decoder.Decode(&p); err != nil {
+		return nil, err
+	}
+	p.path = path
+
+	versionDir := filepath.Dir(path)
+	if timestamp, ok := mavenMetadataTimestamp(versionDir, ""); ok {
+		p.timestamp = timestamp
+	} else if timestamp, ok := mavenMetadataTimestamp(filepath.Dir(versionDir), filepath.Base(versionDir)); ok {
+		p.timestamp = timestamp
+	} else if info, err := f.Stat(); err == nil {
+		p.timestamp = info.ModTime()
+	}
+	return &p, nil
+}
+
+type mavenMetadata struct {
+	LastUpdated string   `xml:"versioning>lastUpdated"`
+	Latest      string   `xml:"versioning>latest"`
+	Release     string   `xml:"versioning>release"`
+	Versions    []string `xml:"versioning>versions>version"`
+}
+
+// lastVersion returns the version that was deployed last according to the metadata of an artifact.
+func (m mavenMetadata) lastVersion() string {
+	switch {
+	case m.Release != "":
+		return m.Release
+	case m.Latest != "":
+		return m.Latest
+	case len(m.Versions) > 0:
+		return m.Versions[len(m.Versions)-1]
+	}
+	return ""
+}
+
+// mavenMetadataTimestamp returns the lastUpdated timestamp of a maven-metadata.xml, or of one of the
+// maven-metadata-<repository>.xml files a local repository keeps, in dir. If version is not empty, dir is the
+// directory of an artifact, and only metadata that lists version as the last one deployed is used.
+func mavenMetadataTimestamp(dir, version string) (time.Time, bool) {
+	paths, _ := filepath.Glob(filepath.Join(dir, "maven-metadata*.xml"))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var metadata mavenMetadata
+		if xml.Unmarshal(content, &metadata) != nil || version != "" && metadata.lastVersion() != version {
+			continue
+		}
+		if timestamp, err := time.Parse(mavenTimestampLayout, strings.TrimSpace(metadata.LastUpdated)); err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// resolve builds the effective model of the pom with the given groupId:artifactId:version key. Like Maven, it first
+// merges the pom with its parents and only then interpolates, so properties of a child also apply to the
+// dependencies it inherits.
+func (m *MavenReader) resolve(key string) (*mavenModel, error) {
+	if model, ok := m.models[key]; ok {
+		return model, nil
+	}
+	p, ok := m.poms[key]
+	if !ok {
+		return nil, nil
+	}
+	if m.resolving[key] {
+		return nil, fmt.Errorf("%s inherits from or imports itself", key)
+	}
+	m.resolving[key] = true
+	defer delete(m.resolving, key)
+
+	groupID, artifactID, version := p.coordinates()
+	model := &mavenModel{
+		groupID:    groupID,
+		artifactID: artifactID,
+		version:    version,
+		properties: make(map[string]string),
+		managed:    make(map[string]pomDependency),
+	}
+
+	// Start with everything inherited from the parent. A parent that is not in the repository only contributes the
+	// coordinates that were already taken from the parent element.
+	var parent *mavenModel
+	if p.Parent != nil {
+		parentKey := strings.TrimSpace(p.Parent.GroupID) + ":" + strings.TrimSpace(p.Parent.ArtifactID) + ":" +
+			strings.TrimSpace(p.Parent.Version)
+		var err error
+		if parent, err = m.resolve(parentKey); err != nil {
+			return nil, err
+		}
+	}
+	if parent != nil {
+		for name, value := range parent.properties {
+			model.properties[name] = value
+		}
+		model.rawManaged = append(model.rawManaged, parent.rawManaged...)
+		model.rawDependencies = append(model.rawDependencies, parent.rawDependencies...)
+	}
+	for name, value := range p.Properties {
+		model.properties[name] = value
+	}
+	// Declarations of the pom itself come last, so they override the inherited ones with the same key below
+	model.rawManaged = append(model.rawManaged, p.ManagedDependencies...)
+	model.rawDependencies = append(model.rawDependencies, p.Dependencies...)
+
+	model.addBuiltinProperties(p)
+	model.groupID = model.interpolate(model.groupID)
+	model.version = model.interpolate(model.version)
+	model.addBuiltinProperties(p)
+
+	for _, dependency := range model.rawManaged {
+		dependency = model.interpolateDependency(dependency)
+		if dependency.Scope == "import" && dependency.Type == "pom" {
+			bom, err := m.resolve(dependency.key() + ":" + dependency.Version)
+			if err != nil {
+				return nil, err
+			}
+			if bom != nil {
+				for dependencyKey, imported := range bom.managed {
+					if _, ok := model.managed[dependencyKey]; !ok {
+						model.managed[dependencyKey] = imported
+					}
+				}
+			}
+			continue
+		}
+		model.managed[dependency.key()] = dependency
+	}
+
+	index := make(map[string]int, len(model.rawDependencies))
+	for _, dependency := range model.rawDependencies {
+		dependency = model.interpolateDependency(dependency)
+		if managed, ok := model.managed[dependency.key()]; ok {
+			if dependency.Version == "" {
+				dependency.Version = managed.Version
+			}
+			if dependency.Scope == "" {
+				dependency.Scope = managed.Scope
+			}
+		}
+		if i, ok := index[dependency.key()]; ok {
+			model.dependencies[i] = dependency
+		} else {
+			index[dependency.key()] = len(model.dependencies)
+			model.dependencies = append(model.dependencies, dependency)
+		}
+	}
+
+	m.models[key] = model
+	return model, nil
+}
+
+// addBuiltinProperties adds the properties Maven derives from the model itself.
+func (model *mavenModel) addBuiltinProperties(p *pom) {
+	for _, prefix := range []string{"project.", "pom."} {
+		model.properties[prefix+"groupId"] = model.groupID
+		model.properties[prefix+"artifactId"] = model.artifactID
+		model.properties[prefix+"version"] = model.version
+	}
+	if p.Parent != nil {
+		for _, prefix := range []string{"project.parent.", "parent."} {
+			model.properties[prefix+"groupId"] = strings.TrimSpace(p.Parent.GroupID)
+			model.properties[prefix+"artifactId"] = strings.TrimSpace(p.Parent.ArtifactID)
+			model.properties[prefix+"version"] = strings.TrimSpace(p.Parent.Version)
+		}
+	}
+}
+
+// inTree reports whether the dependency is part of the dependency tree of dependents, which is not the case for test
+// and system scoped dependencies.
+func (dependency pomDependency) inTree() bool {
+	if dependency.Scope == "test" || dependency.Scope == "system" {
+		return false
+	}
+	return dependency.GroupID != "" && dependency.ArtifactID != ""
+}
+
+func (model *mavenModel) interpolateDependency(dependency pomDependency) pomDependency {
+	dependency.GroupID = model.interpolate(dependency.GroupID)
+	dependency.ArtifactID = model.interpolate(dependency.ArtifactID)
+	dependency.Version = model.interpolate(dependency.Version)
+	dependency.Scope = model.interpolate(dependency.Scope)
+	dependency.Type = model.interpolate(dependency.Type)
+	return dependency
+}
+
+// interpolate replaces ${property} references with the values of the properties of the model. References to
+// unknown properties, such as system properties, are left as they are.
+func (model *mavenModel) interpolate(value string) string {
+	value = strings.TrimSpace(value)
+	for i := 0; i < maxInterpolationDepth && strings.Contains(value, "${"); i++ {
+		expanded := propertyReference.ReplaceAllStringFunc(value, func(reference string) string {
+			if replacement, ok := model.properties[reference[2:len(reference)-1]]; ok {
+				return replacement
+			}
+			return reference
+		})
+		if expanded == value {
+			break
+		}
+		value = expanded
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+package ingest
+
+import (
+	"testing"
+)
+
+func TestMavenReaderResolvesEffectivePoms(t *testing.T) {
+	if format, err := Detect("testdata/maven"); err != nil || format.Name != "maven" {
+		t.Fatalf("Expected the fixture to be detected as a Maven repository, got %v (%v)", format.Name, err)
+	}
+
+	source, err := Open("testdata/maven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	packages := make(map[string]map[string]map[string]string)
+	timestamps := make(map[string]string)
+	for {
+		pkg, err := source.Next()
+		if err != nil {
+			break
+		}
+		packages[pkg.Name] = make(map[string]map[string]string)
+		for version, info := range pkg.Versions {
+			packages[pkg.Name][version] = info.Dependencies
+			timestamps[pkg.Name+"@"+version] = info.Timestamp
+		}
+	}
+
+	t.Run("Reports poms without coordinates", func(t *testing.T) {
+		if skipped := source.Skipped(); len(skipped) != 1 {
+			t.Errorf("Expected the broken pom to be skipped, got %v", skipped)
+		}
+	})
+
+	t.Run("Names packages groupId:artifactId", func(t *testing.T) {
+		for _, name := range []string{"org.example:parent", "org.example:bom", "org.example:core", "org.example:app"} {
+			if _, ok := packages[name]; !ok {
+				t.Errorf("Expected package %s, got %v", name, packages)
+			}
+		}
+		if len(packages["org.example:app"]) != 2 {
+			t.Errorf("Expected 2 versions of org.example:app, got %v", packages["org.example:app"])
+		}
+	})
+
+	t.Run("Resolves inheritance, dependency management and properties", func(t *testing.T) {
+		expected := map[string]string{
+			"org.example:core":       "[1.0,2.0)", // Managed by the parent through a property
+			"com.google.guava:guava": "31.1-jre",  // Managed by a bill of materials imported by the parent
+			"org.slf4j:slf4j-api":    "2.0.0",     // Inherited from the parent, interpolated with the child's property
+		}
+		deps := packages["org.example:app"]["1.0"]
+		if len(deps) != len(expected) {
+			t.Errorf("Expected dependencies %v, got %v", expected, deps)
+		}
+		for name, constraint := range expected {
+			if deps[name] != constraint {
+				t.Errorf("Expected %s to be required as %s, got %q", name, constraint, deps[name])
+			}
+		}
+		if constraint := packages["org.example:core"]["1.2"]["org.slf4j:slf4j-api"]; constraint != "1.7.36" {
+			t.Errorf("Expected core to inherit slf4j-api from its parent, got %q", constraint)
+		}
+		if deps := packages["org.example:app"]["1.1"]; len(deps) != 1 || deps["org.example:core"] != "1.2" {
+			t.Errorf("Unexpected dependencies for app 1.1: %v", deps)
+		}
+	})
+
+	t.Run("Uses the maven-metadata.xml of the artifact for the timestamp of its release", func(t *testing.T) {
+		if timestamp := timestamps["org.example:app@1.1"]; timestamp != "2021-04-22T20:15:37Z" {
+			t.Errorf("Unexpected timestamp for app 1.1: %s", timestamp)
+		}
+		if timestamp := timestamps["org.example:app@1.0"]; timestamp == "" || timestamp == "2021-04-22T20:15:37Z" {
+			t.Errorf("Expected app 1.0 to fall back to the modification time of its pom, got %q", timestamp)
+		}
+		if timestamp := timestamps["org.example:core@1.2"]; timestamp == "" {
+			t.Error("Expected core 1.2 to fall back to the modification time of its pom")
+		}
+	})
+}
@@ -0,0 +1,349 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func init() {
+	Register(Format{
+		Name:       "npm",
+		Extensions: []string{".json", ".jsonl", ".ndjson"},
+		Sniff:      sniffNpm,
+		New:        NewNpmReader,
+		Ecosystem:  graph.NPM,
+	})
+}
+
+// npmWrapperKeys are the top-level fields of the responses of _all_docs and _changes, the CouchDB endpoints that are
+// used to dump the npm registry.
+var npmWrapperKeys = map[string]bool{
+	"total_rows": true,
+	"offset":     true,
+	"rows":       true,
+	"results":    true,
+	"last_seq":   true,
+	"pending":    true,
+}
+
+// sniffNpm recognises CouchDB responses and registry documents, which carry CouchDB's _rev field.
+func sniffNpm(header []byte) bool {
+	if firstNonSpace(header) != '{' {
+		return false
+	}
+	return bytes.Contains(header, []byte(`"total_rows"`)) || bytes.Contains(header, []byte(`"last_seq"`)) ||
+		bytes.Contains(header, []byte(`"_rev"`)) || bytes.Contains(header, []byte(`"seq"`))
+}
+
+// npmDependencies accepts the dependency objects of registry documents. Some very old documents list dependencies as
+// an array of names, and some contain values that are not strings; neither should make the whole document unreadable.
+type npmDependencies map[string]string
+
+func (d *npmDependencies) UnmarshalJSON(data []byte) error {
+	*d = make(npmDependencies)
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err == nil {
+		for name, raw := range object {
+			var constraint string
+			if json.Unmarshal(raw, &constraint) == nil {
+				(*d)[name] = constraint
+			}
+		}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		for _, name := range names {
+			(*d)[name] = "*"
+		}
+	}
+	return nil
+}
+
+// npmField accepts the metadata fields of registry documents, which are either a string or an object, such as an
+// author with a name and an email or a repository with a type and a url. Values of any other type are ignored.
+type npmField struct {
+	text   string
+	object map[string]string
+}
+
+func (f *npmField) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &f.text) == nil {
+		return nil
+	}
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) == nil {
+		f.object = make(map[string]string, len(object))
+		for key, raw := range object {
+			var value string
+			if json.Unmarshal(raw, &value) == nil {
+				f.object[key] = value
+			}
+		}
+	}
+	return nil
+}
+
+// value returns the string the field was written as, or else the key of the object it was written as.
+func (f npmField) value(key string) string {
+	if f.text != "" {
+		return f.text
+	}
+	return f.object[key]
+}
+
+type npmVersion struct {
+	Dependencies         npmDependencies `json:"dependencies"`
+	PeerDependencies     npmDependencies `json:"peerDependencies"`
+	OptionalDependencies npmDependencies `json:"optionalDependencies"`
+	Author               npmField        `json:"author"`
+	License              npmField        `json:"license"`
+	Repository           npmField        `json:"repository"`
+	Description          npmField        `json:"description"`
+}
+
+type npmDocument struct {
+	ID       string                `json:"_id"`
+	Name     string                `json:"name"`
+	Deleted  bool                  `json:"_deleted"`
+	Versions map[string]npmVersion `json:"versions"`
+	Time     map[string]string     `json:"time"`
+}
+
+// npmRow is a row of _all_docs or a result of _changes. Both wrap the document in the doc field.
+type npmRow struct {
+	ID      string          `json:"id"`
+	Deleted bool            `json:"deleted"`
+	Doc     json.RawMessage `json:"doc"`
+}
+
+// NpmReader reads dumps of the npm registry: the response of _all_docs?include_docs=true, the response of
+// _changes?include_docs=true, or a newline-delimited stream of _changes results or registry documents. Deleted
+// packages and design documents are skipped. Documents that cannot be decoded are recorded in Malformed with their
+// position in the dump, which is the line number for newline-delimited input, and reading continues with the next
+// one. Peer and optional dependencies are added to the dependencies of a version, with their kind in its Kinds.
+type NpmReader struct {
+	dec     *json.Decoder // Decodes wrapped responses, and documents that span several lines
+	lines   *bufio.Reader // Reads newline-delimited input, one record per line
+	wrapped bool          // Whether the input is a single _all_docs or _changes response
+	inArray bool
+	done    bool
+	record  int // The position of the current record, which is its line for newline-delimited input
+
+	Malformed []RowError
+}
+
+// NewNpmReader creates an NpmReader reading from r.
+func NewNpmReader(r io.Reader) (Source, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+
+	n := &NpmReader{}
+	if key, ok := firstKey(header); ok && npmWrapperKeys[key] {
+		n.wrapped = true
+		n.dec = json.NewDecoder(reader)
+		if err := expectDelim(n.dec, '{'); err != nil {
+			return nil, err
+		}
+	} else if newlineDelimited(header) {
+		n.lines = reader
+	} else {
+		n.dec = json.NewDecoder(reader)
+	}
+	return n, nil
+}
+
+// newlineDelimited reports whether the input that starts with header has one record per line: its first line is a
+// complete JSON value, or too long to tell. A document that is spread over several lines, such as a single
+// pretty-printed registry document, is not.
+func newlineDelimited(header []byte) bool {
+	header = bytes.TrimLeft(header, " \t\r\n")
+	end := bytes.IndexByte(header, '\n')
+	return end < 0 && len(header) == sniffLength || end >= 0 && json.Valid(header[:end])
+}
+
+// firstKey returns the name of the first field of the JSON object at the start of header.
+func firstKey(header []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(header))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return "", false
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return "", false
+	}
+	key, ok := token.(string)
+	return key, ok
+}
+
+func (n *NpmReader) Next() (graph.PackageInfo, error) {
+	for {
+		raw, err := n.nextRecord()
+		if err != nil {
+			return graph.PackageInfo{}, err
+		}
+		pkg, ok, err := n.convert(raw)
+		if err != nil {
+			n.Malformed = append(n.Malformed, RowError{Line: n.record, Err: err})
+			continue
+		}
+		if ok {
+			return pkg, nil
+		}
+	}
+}
+
+// nextRecord returns the raw JSON of the next row, result or document. In newline-delimited input, that is the next
+// line that is not empty, which does not have to be valid JSON; convert reports it if it is not.
+func (n *NpmReader) nextRecord() (json.RawMessage, error) {
+	if n.lines != nil {
+		for {
+			line, err := n.lines.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if len(line) == 0 && err == io.EOF {
+				return nil, io.EOF
+			}
+			n.record++
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				return line, nil
+			}
+		}
+	}
+
+	var raw json.RawMessage
+	if !n.wrapped {
+		n.record++
+		err := n.dec.Decode(&raw)
+		return raw, err
+	}
+
+	for !n.done {
+		if !n.inArray {
+			if err := n.seekRecords(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !n.dec.More() {
+			if _, err := n.dec.Token(); err != nil {
+				return nil, err
+			}
+			n.inArray = false
+			continue
+		}
+		n.record++
+		err := n.dec.Decode(&raw)
+		return raw, err
+	}
+	return nil, io.EOF
+}
+
+// seekRecords skips the fields of a wrapped response until the decoder is positioned inside the rows or results
+// array.
+func (n *NpmReader) seekRecords() error {
+	for n.dec.More() {
+		token, err := n.dec.Token()
+		if err != nil {
+			return err
+		}
+		if token == "rows" || token == "results" {
+			if err := expectDelim(n.dec, '['); err != nil {
+				return err
+			}
+			n.inArray = true
+			return nil
+		}
+		var skipped json.RawMessage
+		if err := n.dec.Decode(&skipped); err != nil {
+			return err
+		}
+	}
+	n.done = true
+	return nil
+}
+
+// convert turns a row, result or document into a PackageInfo. It reports false for records that do not describe a
+// package, such as deletions and design documents.
+func (n *NpmReader) convert(raw json.RawMessage) (graph.PackageInfo, bool, error) {
+	var row npmRow
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return graph.PackageInfo{}, false, err
+	}
+	if row.Deleted {
+		return graph.PackageInfo{}, false, nil
+	}
+	docJSON := raw
+	if row.Doc != nil {
+		docJSON = row.Doc
+	}
+	if bytes.Equal(bytes.TrimSpace(docJSON), []byte("null")) {
+		return graph.PackageInfo{}, false, nil
+	}
+
+	var doc npmDocument
+	if err := json.Unmarshal(docJSON, &doc); err != nil {
+		return graph.PackageInfo{}, false, fmt.Errorf("document %s: %w", row.ID, err)
+	}
+	name := doc.Name
+	if name == "" {
+		name = doc.ID
+	}
+	if doc.Deleted || name == "" || strings.HasPrefix(name, "_design/") {
+		return graph.PackageInfo{}, false, nil
+	}
+
+	pkg := graph.PackageInfo{Name: name, Versions: make(map[string]graph.VersionInfo, len(doc.Versions))}
+	for version, info := range doc.Versions {
+		dependencies := make(map[string]string, len(info.Dependencies))
+		var kinds map[string]string
+		// Regular dependencies take precedence, optional dependencies are often repeated there with the same range
+		groups := []struct {
+			kind         string
+			dependencies npmDependencies
+		}{{"", info.Dependencies}, {"optional", info.OptionalDependencies}, {"peer", info.PeerDependencies}}
+		for _, group := range groups {
+			for dependency, constraint := range group.dependencies {
+				if _, ok := dependencies[dependency]; ok {
+					continue
+				}
+				dependencies[dependency] = constraint
+				if group.kind != "" {
+					if kinds == nil {
+						kinds = make(map[string]string)
+					}
+					kinds[dependency] = group.kind
+				}
+			}
+		}
+		pkg.Versions[version] = graph.VersionInfo{
+			Timestamp:    doc.Time[version],
+			Dependencies: dependencies,
+			Kinds:        kinds,
+			Author:       info.Author.value("name"),
+			License:      info.License.value("type"),
+			Repository:   info.Repository.value("url"),
+			Description:  info.Description.value(""),
+		}
+	}
+	return pkg, true, nil
+}
+
+func (n *NpmReader) Skipped() []RowError {
+	return n.Malformed
+}
+
+func (n *NpmReader) Close() error {
+	return nil
+}
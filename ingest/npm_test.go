@@ -0,0 +1,130 @@
+package ingest
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func readPackages(t *testing.T, path string) map[string]graph.PackageInfo {
+	source, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	packages := make(map[string]graph.PackageInfo)
+	for {
+		pkg, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		packages[pkg.Name] = pkg
+	}
+	if skipped := source.Skipped(); len(skipped) != 0 {
+		t.Errorf("Expected no skipped records, got %v", skipped)
+	}
+	return packages
+}
+
+func TestNpmReaderAllDocs(t *testing.T) {
+	if format, err := Detect("testdata/npm_all_docs.json"); err != nil || format.Name != "npm" {
+		t.Fatalf("Expected the fixture to be detected as npm, got %v (%v)", format.Name, err)
+	}
+	packages := readPackages(t, "testdata/npm_all_docs.json")
+
+	t.Run("Skips design documents and deleted packages", func(t *testing.T) {
+		if len(packages) != 2 {
+			t.Errorf("Expected 2 packages, got %d", len(packages))
+		}
+	})
+
+	t.Run("Uses the time map for the timestamps", func(t *testing.T) {
+		leftPad := packages["left-pad"]
+		if timestamp := leftPad.Versions["1.1.0"].Timestamp; timestamp != "2016-03-23T10:52:47.411Z" {
+			t.Errorf("Unexpected timestamp for left-pad 1.1.0: %s", timestamp)
+		}
+		if len(leftPad.Versions["1.1.0"].Dependencies) != 0 {
+			t.Errorf("Expected left-pad 1.1.0 to have no dependencies")
+		}
+	})
+
+	t.Run("Merges regular, peer and optional dependencies", func(t *testing.T) {
+		widget := packages["@scope/widget"]
+		expected := map[string]string{"left-pad": "^1.0.0", "react": ">=15", "fsevents": "~1.2.0"}
+		deps := widget.Versions["2.0.0"].Dependencies
+		if len(deps) != len(expected) {
+			t.Errorf("Expected dependencies %v, got %v", expected, deps)
+		}
+		for name, constraint := range expected {
+			if deps[name] != constraint {
+				t.Errorf("Expected %s to be required as %s, got %q", name, constraint, deps[name])
+			}
+		}
+		expectedKinds := map[string]string{"react": "peer", "fsevents": "optional"}
+		if kinds := widget.Versions["2.0.0"].Kinds; !reflect.DeepEqual(kinds, expectedKinds) {
+			t.Errorf("Expected the kinds %v, got %v", expectedKinds, kinds)
+		}
+		if constraint := widget.Versions["2.1.0"].Dependencies["left-pad"]; constraint != "*" {
+			t.Errorf("Expected dependencies given as an array to accept any version, got %q", constraint)
+		}
+	})
+
+	t.Run("Reads the metadata of every version", func(t *testing.T) {
+		expected := graph.Metadata{Author: "Jane Doe", License: "MIT", Repository: "git+https://github.com/scope/widget.git", Description: "A widget"}
+		if metadata := packages["@scope/widget"].Versions["2.0.0"].Metadata(); metadata == nil || *metadata != expected {
+			t.Errorf("Expected metadata %+v, got %+v", expected, metadata)
+		}
+		if metadata := packages["@scope/widget"].Versions["2.1.0"].Metadata(); metadata != nil {
+			t.Errorf("Expected no metadata for a version without it, got %+v", metadata)
+		}
+		leftPad := packages["left-pad"].Versions["1.1.0"]
+		if leftPad.License != "WTFPL" || leftPad.Description != "" {
+			t.Errorf("Expected the license object to be read and the description array to be ignored, got %+v", leftPad)
+		}
+	})
+}
+
+func TestNpmReaderChangesFeed(t *testing.T) {
+	packages := readPackages(t, "testdata/npm_changes.jsonl")
+	if len(packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %d", len(packages))
+	}
+	if constraint := packages["react"].Versions["15.0.0"].Dependencies["loose-envify"]; constraint != "^1.1.0" {
+		t.Errorf("Unexpected constraint for react 15.0.0: %q", constraint)
+	}
+	if timestamp := packages["left-pad"].Versions["1.0.0"].Timestamp; timestamp != "2014-03-13T08:20:40.473Z" {
+		t.Errorf("Unexpected timestamp for left-pad 1.0.0: %s", timestamp)
+	}
+}
+
+func TestNpmReaderSkipsBrokenLines(t *testing.T) {
+	source, err := Open("testdata/npm_changes_broken.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	var names []string
+	for {
+		pkg, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected the broken line to be skipped, got %v", err)
+		}
+		names = append(names, pkg.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"left-pad", "react"}) {
+		t.Errorf("Expected the packages before and after the broken line, got %v", names)
+	}
+	if skipped := source.Skipped(); len(skipped) != 1 || skipped[0].Line != 2 {
+		t.Errorf("Expected line 2 to be skipped, got %v", skipped)
+	}
+}
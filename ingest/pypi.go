@@ -0,0 +1,218 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/AJMBrands/SoftwareThatMatters/graph"
+)
+
+func init() {
+	Register(Format{
+		Name:       "pypi",
+		Extensions: []string{".json", ".jsonl", ".ndjson"},
+		Sniff:      sniffPyPI,
+		New:        NewPyPIReader,
+		Ecosystem:  graph.PyPI,
+	})
+}
+
+// sniffPyPI recognises documents of the PyPI JSON API, which start with the info object of a project.
+func sniffPyPI(header []byte) bool {
+	first := firstNonSpace(header)
+	return (first == '{' || first == '[') && bytes.Contains(header, []byte(`"info"`)) &&
+		(bytes.Contains(header, []byte(`"requires_dist"`)) || bytes.Contains(header, []byte(`"releases"`)))
+}
+
+type pypiFile struct {
+	UploadTime string `json:"upload_time_iso_8601"`
+	// Older documents only have upload_time, which is in UTC but has no zone
+	UploadTimeNoZone string `json:"upload_time"`
+}
+
+// uploadTime returns the time the file was uploaded.
+func (f pypiFile) uploadTime() (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, f.UploadTime); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05", f.UploadTimeNoZone); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+type pypiDocument struct {
+	Info struct {
+		Name         string            `json:"name"`
+		Version      string            `json:"version"`
+		RequiresDist []string          `json:"requires_dist"`
+		Author       string            `json:"author"`
+		License      string            `json:"license"`
+		Summary      string            `json:"summary"`
+		HomePage     string            `json:"home_page"`
+		ProjectURLs  map[string]string `json:"project_urls"`
+	} `json:"info"`
+	Releases map[string][]pypiFile `json:"releases"`
+	URLs     []pypiFile            `json:"urls"`
+}
+
+// PyPIReader reads documents of the PyPI JSON API: a single document, a JSON array of documents, or one document per
+// line. Both the project documents of /pypi/<project>/json and the version documents of
+// /pypi/<project>/<version>/json are understood. The requires_dist of a document belongs to the version in its info,
+// the releases of a project document provide the other versions and the upload times. Requirements that only apply to
+// an extra are dropped; environment markers are kept in the constraint, where the PyPI ecosystem ignores them.
+type PyPIReader struct {
+	dec    *json.Decoder
+	array  bool
+	read   bool
+	record int
+
+	result    []graph.PackageInfo
+	Malformed []RowError
+}
+
+// NewPyPIReader creates a PyPIReader reading from r.
+func NewPyPIReader(r io.Reader) (Source, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	p := &PyPIReader{dec: json.NewDecoder(reader)}
+	if firstNonSpace(header) == '[' {
+		p.array = true
+		if err := expectDelim(p.dec, '['); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *PyPIReader) Next() (graph.PackageInfo, error) {
+	if !p.read {
+		if err := p.readAll(); err != nil {
+			return graph.PackageInfo{}, err
+		}
+		p.read = true
+	}
+	if len(p.result) == 0 {
+		return graph.PackageInfo{}, io.EOF
+	}
+	next := p.result[0]
+	p.result = p.result[1:]
+	return next, nil
+}
+
+func (p *PyPIReader) Skipped() []RowError {
+	return p.Malformed
+}
+
+func (p *PyPIReader) Close() error {
+	return nil
+}
+
+// readAll reads every document. Documents of the same project are merged, so the whole input has to be read before
+// the first package can be returned.
+func (p *PyPIReader) readAll() error {
+	packages := make(map[string]*graph.PackageInfo)
+	for !p.array || p.dec.More() {
+		var doc pypiDocument
+		err := p.dec.Decode(&doc)
+		if err == io.EOF && !p.array {
+			break
+		}
+		p.record++
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			p.Malformed = append(p.Malformed, RowError{Line: p.record, Err: err})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		p.add(doc, packages)
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.result = append(p.result, *packages[name])
+	}
+	return nil
+}
+
+func (p *PyPIReader) add(doc pypiDocument, packages map[string]*graph.PackageInfo) {
+	if doc.Info.Name == "" {
+		p.Malformed = append(p.Malformed, RowError{Line: p.record, Err: errors.New("document has no project name")})
+		return
+	}
+	name := graph.NormalizePyPIName(doc.Info.Name)
+	pkg, ok := packages[name]
+	if !ok {
+		pkg = &graph.PackageInfo{Name: name, Versions: make(map[string]graph.VersionInfo)}
+		packages[name] = pkg
+	}
+
+	for version, files := range doc.Releases {
+		info := pkg.Versions[version]
+		if info.Timestamp == "" {
+			info.Timestamp = earliestUpload(files)
+		}
+		if info.Dependencies == nil {
+			info.Dependencies = make(map[string]string)
+		}
+		pkg.Versions[version] = info
+	}
+
+	if doc.Info.Version == "" {
+		return
+	}
+	info := pkg.Versions[doc.Info.Version]
+	if timestamp := earliestUpload(doc.URLs); timestamp != "" {
+		info.Timestamp = timestamp
+	}
+	info.Author, info.License, info.Description = doc.Info.Author, doc.Info.License, doc.Info.Summary
+	info.Repository = doc.Info.HomePage
+	for _, label := range []string{"Source", "Source Code", "Repository", "Code"} {
+		if url, ok := doc.Info.ProjectURLs[label]; ok {
+			info.Repository = url
+			break
+		}
+	}
+	info.Dependencies = make(map[string]string, len(doc.Info.RequiresDist))
+	for _, requirementString := range doc.Info.RequiresDist {
+		requirement, err := graph.ParseRequirement(requirementString)
+		if err != nil {
+			p.Malformed = append(p.Malformed, RowError{Line: p.record, Err: fmt.Errorf("%s %s: %w", name, doc.Info.Version, err)})
+			continue
+		}
+		if requirement.OnlyForExtra() {
+			continue
+		}
+		info.Dependencies[requirement.Name] = requirement.Constraint()
+	}
+	pkg.Versions[doc.Info.Version] = info
+}
+
+// earliestUpload returns the time the first of the files was uploaded, formatted as RFC 3339.
+func earliestUpload(files []pypiFile) string {
+	var earliest time.Time
+	for _, file := range files {
+		if t, ok := file.uploadTime(); ok && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	if earliest.IsZero() {
+		return ""
+	}
+	return earliest.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import "testing"
+
+func TestPyPIReader(t *testing.T) {
+	if format, err := Detect("testdata/pypi_projects.json"); err != nil || format.Name != "pypi" {
+		t.Fatalf("Expected the fixture to be detected as pypi, got %v (%v)", format.Name, err)
+	}
+	packages := readPackages(t, "testdata/pypi_projects.json")
+
+	t.Run("Merges documents of the same project", func(t *testing.T) {
+		if len(packages) != 2 {
+			t.Fatalf("Expected 2 packages, got %d", len(packages))
+		}
+		if versions := len(packages["sample-project"].Versions); versions != 2 {
+			t.Errorf("Expected 2 versions of sample-project, got %d", versions)
+		}
+	})
+
+	t.Run("Uses the earliest upload as the timestamp", func(t *testing.T) {
+		sample := packages["sample-project"]
+		if timestamp := sample.Versions["1.0.0"].Timestamp; timestamp != "2019-04-30T09:00:00Z" {
+			t.Errorf("Unexpected timestamp for 1.0.0: %s", timestamp)
+		}
+		if timestamp := sample.Versions["2.0.0"].Timestamp; timestamp != "2021-01-02T03:04:05Z" {
+			t.Errorf("Unexpected timestamp for 2.0.0: %s", timestamp)
+		}
+	})
+
+	t.Run("Reads the metadata of the version of the document", func(t *testing.T) {
+		info := packages["sample-project"].Versions["2.0.0"]
+		if info.Author != "A. Random Developer" || info.License != "MIT" || info.Description != "A sample Python project" ||
+			info.Repository != "https://github.com/pypa/sampleproject/tree/main" {
+			t.Errorf("Unexpected metadata %+v", info.Metadata())
+		}
+	})
+
+	t.Run("Parses requires_dist and drops extras", func(t *testing.T) {
+		expected := map[string]string{
+			"requests":           ">=2.8.1,<3",
+			"importlib-metadata": `* ; python_version < "3.8"`,
+		}
+		deps := packages["sample-project"].Versions["2.0.0"].Dependencies
+		if len(deps) != len(expected) {
+			t.Fatalf("Expected %d dependencies, got %v", len(expected), deps)
+		}
+		for name, constraint := range expected {
+			if deps[name] != constraint {
+				t.Errorf("Expected %s to be %q, got %q", name, constraint, deps[name])
+			}
+		}
+		if deps := packages["sample-project"].Versions["1.0.0"].Dependencies; deps["six"] != "*" {
+			t.Errorf("Expected 1.0.0 to depend on six, got %v", deps)
+		}
+	})
+}
@@ -0,0 +1,5 @@
+module example.com/app
+
+go 1.17
+
+require github.com/BurntSushi/toml v0.4.0
@@ -0,0 +1,18 @@
+module example.com/app
+
+go 1.18
+
+require (
+	"github.com/BurntSushi/toml" v0.4.0
+	golang.org/x/text v0.3.6 // indirect
+	example.com/local v1.0.0
+)
+
+exclude golang.org/x/text v0.3.6
+
+replace (
+	github.com/BurntSushi/toml v0.4.0 => github.com/BurntSushi/toml v0.4.1
+	example.com/local => ../local
+)
+
+retract [v1.0.0, v1.0.1] // Broken build